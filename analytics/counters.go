@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+// Listing counts are kept in memory and flushed to the database in batches, so busy
+// listing pages don't cost a write per request. The server flushes them when it shuts
+// down, but counts since the last flush are lost if the process is killed
+type counterKey struct {
+	ListingId			uuid.UUID
+	Day					time.Time
+}
+
+type counter struct {
+	impressions			int64
+	views				int64
+	viewers				map[string]struct{}
+}
+
+type buffer struct {
+	mu					sync.Mutex
+	counters			map[counterKey]*counter
+}
+
+var listingCounters = buffer{counters: map[counterKey]*counter{}}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// Returns the counter of a listing for today. The lock must be held
+func (b *buffer) get(listingId uuid.UUID) *counter {
+	key := counterKey{ListingId: listingId, Day: today()}
+	if b.counters[key] == nil {
+		b.counters[key] = &counter{viewers: map[string]struct{}{}}
+	}
+	return b.counters[key]
+}
+
+// Counts listings shown in a list or search results
+func RecordImpressions(listings []models.Listing) {
+	listingCounters.mu.Lock()
+	defer listingCounters.mu.Unlock()
+	for _, listing := range listings {
+		listingCounters.get(listing.ID).impressions++
+	}
+}
+
+// Counts a listing being opened by a viewer (a user, guest or IP address)
+func RecordView(listingId uuid.UUID, viewerKey string) {
+	listingCounters.mu.Lock()
+	defer listingCounters.mu.Unlock()
+	listingCounter := listingCounters.get(listingId)
+	listingCounter.views++
+	listingCounter.viewers[viewerKey] = struct{}{}
+}
+
+// Writes the buffered counts to the database
+func Flush(db *gorm.DB) {
+	listingCounters.mu.Lock()
+	counters := listingCounters.counters
+	listingCounters.counters = map[counterKey]*counter{}
+	listingCounters.mu.Unlock()
+
+	for key, listingCounter := range counters {
+		viewerKeys := []string{}
+		for viewerKey := range listingCounter.viewers {
+			viewerKeys = append(viewerKeys, viewerKey)
+		}
+		models.AddListingStats(db, key.ListingId, key.Day, listingCounter.impressions, listingCounter.views, viewerKeys)
+	}
+}
@@ -0,0 +1,85 @@
+package auctions
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/payments"
+)
+
+// Buys a listing at its buy-now price, closing the auction and recording the buyer.
+// The listing row is locked so that concurrent purchases (or bids) can't both win
+func BuyNow(db *gorm.DB, listingId uuid.UUID, buyerId uuid.UUID) (*models.AuctionResult, *BidError) {
+	var result *models.AuctionResult
+	var bidErr *BidError
+	err := db.Transaction(func(tx *gorm.DB) error {
+		listing := models.Listing{}
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&listing, listingId)
+		if listing.ID == uuid.Nil || !listing.IsPublic() {
+			bidErr = &BidError{Code: 404, Message: "Listing does not exist!"}
+			return nil
+		}
+		if buyerId == listing.AuctioneerId {
+			bidErr = &BidError{Code: 403, Message: "You cannot buy your own product!"}
+			return nil
+		}
+		if listing.FinalizedAt != nil || listing.IsClosed() {
+			bidErr = &BidError{Code: 410, Message: "This auction is closed!"}
+			return nil
+		}
+		if listing.IsUpcoming() {
+			bidErr = &BidError{Code: 400, Message: "This auction hasn't started yet!"}
+			return nil
+		}
+		highestBid, _ := bidsAggregate(tx, listing, "MAX")
+		if !listing.CanBuyNow(highestBid) {
+			bidErr = &BidError{Code: 400, Message: "Buy now is not available for this listing!"}
+			return nil
+		}
+
+		result = &models.AuctionResult{
+			ListingId: listing.ID,
+			WinnerId:  buyerId,
+			Quantity:  1,
+			UnitPrice: listing.BuyNowPrice.Round(2),
+			Amount:    listing.BuyNowPrice.Round(2),
+			BuyNow:    true,
+		}
+		if listing.RequiresDeposit {
+			if err := payments.HoldForBid(tx, buyerId, listing.ID, result.Amount); err != nil {
+				if err == payments.ErrInsufficientFunds {
+					bidErr = &BidError{Code: 402, Message: "Insufficient wallet balance for this purchase!"}
+					result = nil
+				}
+				return err
+			}
+		}
+		if err := tx.Create(result).Error; err != nil {
+			return err
+		}
+		if listing.RequiresDeposit {
+			if err := settleDeposits(tx, listing, []models.AuctionResult{*result}); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&listing).UpdateColumns(map[string]interface{}{
+			"active":       false,
+			"state":        models.ListingStateEnded,
+			"finalized_at": time.Now().UTC(),
+		}).Error
+	})
+	if bidErr != nil {
+		return nil, bidErr
+	}
+	if err != nil {
+		return nil, &BidError{Code: 500, Message: "Something went wrong!"}
+	}
+	if result != nil {
+		publishClosed(db, listingId, []models.AuctionResult{*result})
+	}
+	return result, bidErr
+}
@@ -0,0 +1,50 @@
+package auctions
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/payments"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
+)
+
+// Cancels a listing that hasn't ended, releasing every deposit held for it. The listing
+// row is locked so that it can't be finalized or bought at the same time
+func Cancel(db *gorm.DB, listingId uuid.UUID, reason string) *BidError {
+	var cancelErr *BidError
+	err := db.Transaction(func(tx *gorm.DB) error {
+		listing := models.Listing{}
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&listing, listingId)
+		if listing.ID == uuid.Nil {
+			cancelErr = &BidError{Code: 404, Message: "Invalid listing!"}
+			return nil
+		}
+		if listing.FinalizedAt != nil || !listing.CanTransitionTo(models.ListingStateCancelled) {
+			cancelErr = &BidError{Code: 400, Message: "This listing can no longer be cancelled!"}
+			return nil
+		}
+		if listing.RequiresDeposit {
+			if err := payments.ReleaseListingHolds(tx, listing.ID, nil); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&listing).UpdateColumns(map[string]interface{}{
+			"active":              false,
+			"state":               models.ListingStateCancelled,
+			"cancellation_reason": reason,
+			"cancelled_at":        time.Now().UTC(),
+		}).Error
+	})
+	if cancelErr != nil {
+		return cancelErr
+	}
+	if err != nil {
+		return &BidError{Code: 500, Message: "Something went wrong!"}
+	}
+	realtime.Publish(db, listingId, realtime.ListingCancelled, map[string]interface{}{"reason": reason})
+	return nil
+}
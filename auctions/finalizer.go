@@ -0,0 +1,94 @@
+package auctions
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/payments"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
+	"github.com/kayprogrammer/bidout-auction-v7/senders"
+)
+
+// Closes a listing and records its winners. A listing is only ever finalized once,
+// so calling this again (e.g from the scheduler after a dutch auction closed on a bid) is a no-op.
+// Listings without a winner are relisted when the seller asked for it
+func Finalize(db *gorm.DB, listingId uuid.UUID) []models.AuctionResult {
+	results := []models.AuctionResult{}
+	finalized := false
+	var relist *models.Listing
+	err := db.Transaction(func(tx *gorm.DB) error {
+		listing := models.Listing{}
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&listing, listingId)
+		if listing.ID == uuid.Nil || listing.FinalizedAt != nil || listing.State != models.ListingStatePublished {
+			return nil
+		}
+
+		bids := []models.Bid{}
+		tx.Order("created_at ASC").Find(&bids, models.Bid{ListingId: listing.ID})
+		results = StrategyFor(listing).SelectWinners(listing, bids)
+		if len(results) > 0 {
+			if err := tx.Create(&results).Error; err != nil {
+				return err
+			}
+		}
+		if listing.RequiresDeposit {
+			if err := settleDeposits(tx, listing, results); err != nil {
+				return err
+			}
+		}
+		finalized = true
+		err := tx.Model(&listing).UpdateColumns(map[string]interface{}{
+			"active":       false,
+			"state":        models.ListingStateEnded,
+			"finalized_at": time.Now().UTC(),
+		}).Error
+		if err != nil || len(results) > 0 || !listing.ShouldAutoRelist() {
+			return err
+		}
+		relist, err = listing.AutoRelistListing(tx)
+		return err
+	})
+	if err == nil && finalized {
+		publishClosed(db, listingId, results)
+	}
+	if err == nil && relist != nil {
+		// Point the listing's subscribers to the relisted copy and alert searches it matches
+		realtime.Publish(db, listingId, realtime.ListingRelisted, map[string]interface{}{"slug": relist.Slug})
+		go senders.AlertSavedSearches("normal", db, *relist)
+	}
+	return results
+}
+
+// Captures what the winners pay from their holds and releases every other bidder's hold
+func settleDeposits(tx *gorm.DB, listing models.Listing, results []models.AuctionResult) error {
+	winnerIds := []uuid.UUID{}
+	for _, result := range results {
+		if err := payments.Capture(tx, result.WinnerId, listing.ID, result.Amount); err != nil {
+			return err
+		}
+		winnerIds = append(winnerIds, result.WinnerId)
+	}
+	return payments.ReleaseListingHolds(tx, listing.ID, winnerIds)
+}
+
+// Tells the listing's subscribers that the auction is over
+func publishClosed(db *gorm.DB, listingId uuid.UUID, results []models.AuctionResult) {
+	winners := []models.AuctionResult{}
+	for _, result := range results {
+		winners = append(winners, result.Init(db))
+	}
+	realtime.Publish(db, listingId, realtime.ListingClosed, map[string]interface{}{"winners": winners})
+}
+
+// Finalizes every published listing whose closing date has passed
+func FinalizeEndedListings(db *gorm.DB) {
+	listingIds := []uuid.UUID{}
+	db.Model(&models.Listing{}).Where("state = ? AND finalized_at IS NULL AND closing_date <= ?", models.ListingStatePublished, time.Now().UTC()).Pluck("id", &listingIds)
+	for _, listingId := range listingIds {
+		Finalize(db, listingId)
+	}
+}
@@ -0,0 +1,80 @@
+package auctions
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+func bidQuantity(bid models.Bid) int {
+	if bid.Quantity < 1 {
+		return 1
+	}
+	return bid.Quantity
+}
+
+// Allocates the units of a lot to the highest bids, earliest bid first on ties.
+// The last winner may get fewer units than they asked for
+func allocateUnits(listing models.Listing, sorted []models.Bid) []models.AuctionResult {
+	results := []models.AuctionResult{}
+	remaining := listing.Quantity
+	for _, bid := range sorted {
+		if remaining < 1 {
+			break
+		}
+		units := bidQuantity(bid)
+		if units > remaining {
+			units = remaining
+		}
+		results = append(results, newResult(listing, bid, bid.Amount, units))
+		remaining -= units
+	}
+	return results
+}
+
+func reprice(results []models.AuctionResult, unitPrice decimal.Decimal) []models.AuctionResult {
+	for i := range results {
+		results[i].UnitPrice = unitPrice.Round(2)
+		results[i].Amount = results[i].UnitPrice.Mul(decimal.NewFromInt(int64(results[i].Quantity)))
+	}
+	return results
+}
+
+// Picks the winners of a multi-quantity lot, one result per winner. Discriminatory lots
+// charge each winner their own bid while uniform lots charge everyone the lowest winning bid.
+// Second-price lots charge everyone the highest losing bid (or the starting price if there's none)
+func selectLotWinners(listing models.Listing, bids []models.Bid, secondPrice bool) []models.AuctionResult {
+	sorted := sortBidsDescending(bids)
+	results := allocateUnits(listing, sorted)
+	if len(results) == 0 {
+		return results
+	}
+	if secondPrice {
+		clearingPrice := listing.Price
+		if len(sorted) > len(results) {
+			clearingPrice = sorted[len(results)].Amount
+		}
+		return reprice(results, clearingPrice)
+	}
+	if listing.PricingRule == models.PricingUniform {
+		return reprice(results, results[len(results)-1].UnitPrice)
+	}
+	return results
+}
+
+// Once every unit of a lot is spoken for, a new bid has to beat the lowest winning bid.
+// The bidder's own previous bid is left out since the new one replaces it
+func validateLotBid(db *gorm.DB, listing models.Listing, bid models.Bid) *BidError {
+	otherBids := []models.Bid{}
+	db.Where("listing_id = ? AND user_id != ?", listing.ID, bid.UserId).Order("created_at ASC").Find(&otherBids)
+	results := allocateUnits(listing, sortBidsDescending(otherBids))
+	allocated := 0
+	for _, result := range results {
+		allocated += result.Quantity
+	}
+	if allocated >= listing.Quantity && bid.Amount.Cmp(results[len(results)-1].UnitPrice) <= 0 {
+		return &BidError{Code: 400, Message: "Bid amount must be more than the lowest winning bid!"}
+	}
+	return nil
+}
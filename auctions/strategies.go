@@ -0,0 +1,286 @@
+package auctions
+
+import (
+	"sort"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+// BidError describes why a bid was rejected and the status code to respond with
+type BidError struct {
+	Code				int
+	Message				string
+}
+
+// Strategy holds the bidding rules of an auction format
+type Strategy interface {
+	// Checks a new bid against the listing's current state
+	ValidateBid(db *gorm.DB, listing models.Listing, bid models.Bid) *BidError
+	// Picks the winning bids (and the amount to be paid) once the listing closes
+	SelectWinners(listing models.Listing, bids []models.Bid) []models.AuctionResult
+	// Reports whether an accepted bid ends the auction immediately
+	ClosesOnBid() bool
+}
+
+// Returns the strategy for the listing's auction type
+func StrategyFor(listing models.Listing) Strategy {
+	switch listing.AuctionType {
+	case models.AuctionSealedFirstPrice:
+		return SealedBid{SecondPrice: false}
+	case models.AuctionSealedSecondPrice:
+		return SealedBid{SecondPrice: true}
+	case models.AuctionDutch:
+		return Dutch{}
+	case models.AuctionReverse:
+		return Reverse{}
+	default:
+		return English{}
+	}
+}
+
+// Sorts bids from the highest amount to the lowest, earliest bid first on ties
+func sortBidsDescending(bids []models.Bid) []models.Bid {
+	sorted := append([]models.Bid{}, bids...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Amount.Equal(sorted[j].Amount) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].Amount.GreaterThan(sorted[j].Amount)
+	})
+	return sorted
+}
+
+func newResult(listing models.Listing, bid models.Bid, unitPrice decimal.Decimal, quantity int) models.AuctionResult {
+	bidId := bid.ID
+	unitPrice = unitPrice.Round(2)
+	return models.AuctionResult{
+		ListingId: listing.ID,
+		WinnerId:  bid.UserId,
+		BidId:     &bidId,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		Amount:    unitPrice.Mul(decimal.NewFromInt(int64(quantity))),
+	}
+}
+
+func bidsAggregate(db *gorm.DB, listing models.Listing, aggregate string) (decimal.Decimal, bool) {
+	var count int64
+	db.Model(&models.Bid{}).Where("listing_id = ?", listing.ID).Count(&count)
+	amount := decimal.NewFromFloat(0.00)
+	if count > 0 {
+		db.Model(&models.Bid{}).Where("listing_id = ?", listing.ID).Select(aggregate + "(amount)").Scan(&amount)
+	}
+	return amount, count > 0
+}
+
+// Returns the bids that would win if the listing closed right now
+func LeadingBids(db *gorm.DB, listing models.Listing) []models.Bid {
+	bids := []models.Bid{}
+	db.Order("created_at ASC").Find(&bids, models.Bid{ListingId: listing.ID})
+	leadingBids := []models.Bid{}
+	for _, result := range StrategyFor(listing).SelectWinners(listing, bids) {
+		for _, bid := range bids {
+			if result.BidId != nil && *result.BidId == bid.ID {
+				leadingBids = append(leadingBids, bid)
+			}
+		}
+	}
+	return leadingBids
+}
+
+// Returns the amount shown as the listing's highest bid while these bids lead (the lowest
+// one for reverse auctions), or false when there are none
+func LeadingAmount(listing models.Listing, leadingBids []models.Bid) (decimal.Decimal, bool) {
+	if len(leadingBids) == 0 {
+		return decimal.Decimal{}, false
+	}
+	amount := leadingBids[0].Amount
+	for _, bid := range leadingBids[1:] {
+		if listing.AuctionType == models.AuctionReverse {
+			amount = decimal.Min(amount, bid.Amount)
+		} else {
+			amount = decimal.Max(amount, bid.Amount)
+		}
+	}
+	return amount, true
+}
+
+// Checks if other bids lead now, or the leading ones changed their amounts
+func LeadChanged(leadingBefore []models.Bid, leadingAfter []models.Bid) bool {
+	if len(leadingBefore) != len(leadingAfter) {
+		return true
+	}
+	amounts := map[uuid.UUID]decimal.Decimal{}
+	for _, bid := range leadingBefore {
+		amounts[bid.ID] = bid.Amount
+	}
+	for _, bid := range leadingAfter {
+		amount, ok := amounts[bid.ID]
+		if !ok || !amount.Equal(bid.Amount) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the previously leading bids whose bidders are no longer leading
+func Outbid(leadingBefore []models.Bid, leadingAfter []models.Bid) []models.Bid {
+	stillLeading := map[uuid.UUID]bool{}
+	for _, bid := range leadingAfter {
+		stillLeading[bid.UserId] = true
+	}
+	outbid := []models.Bid{}
+	for _, bid := range leadingBefore {
+		if !stillLeading[bid.UserId] {
+			outbid = append(outbid, bid)
+		}
+	}
+	return outbid
+}
+
+// ENGLISH (open ascending)
+type English struct{}
+
+func (English) ValidateBid(db *gorm.DB, listing models.Listing, bid models.Bid) *BidError {
+	if bid.Amount.Cmp(listing.Price) < 0 {
+		return &BidError{Code: 400, Message: "Bid amount cannot be less than the bidding price!"}
+	}
+	if listing.IsMultiUnit() {
+		return validateLotBid(db, listing, bid)
+	}
+	highestBid, _ := bidsAggregate(db, listing, "MAX")
+	if bid.Amount.Cmp(highestBid) <= 0 {
+		return &BidError{Code: 400, Message: "Bid amount must be more than the highest bid!"}
+	}
+	return nil
+}
+
+func (English) SelectWinners(listing models.Listing, bids []models.Bid) []models.AuctionResult {
+	if listing.IsMultiUnit() {
+		return selectLotWinners(listing, bids, false)
+	}
+	results := []models.AuctionResult{}
+	if len(bids) > 0 {
+		winningBid := sortBidsDescending(bids)[0]
+		results = append(results, newResult(listing, winningBid, winningBid.Amount, 1))
+	}
+	return results
+}
+
+func (English) ClosesOnBid() bool {
+	return false
+}
+
+// SEALED BID (first-price or second-price/vickrey)
+type SealedBid struct {
+	SecondPrice			bool
+}
+
+func (SealedBid) ValidateBid(db *gorm.DB, listing models.Listing, bid models.Bid) *BidError {
+	if bid.Amount.Cmp(listing.Price) < 0 {
+		return &BidError{Code: 400, Message: "Bid amount cannot be less than the bidding price!"}
+	}
+	existingBid := models.Bid{}
+	db.Take(&existingBid, models.Bid{UserId: bid.UserId, ListingId: listing.ID})
+	if existingBid.ID != uuid.Nil {
+		return &BidError{Code: 400, Message: "You have already placed a sealed bid on this listing!"}
+	}
+	return nil
+}
+
+func (s SealedBid) SelectWinners(listing models.Listing, bids []models.Bid) []models.AuctionResult {
+	if listing.IsMultiUnit() {
+		return selectLotWinners(listing, bids, s.SecondPrice)
+	}
+	results := []models.AuctionResult{}
+	if len(bids) == 0 {
+		return results
+	}
+	sorted := sortBidsDescending(bids)
+	amount := sorted[0].Amount
+	if s.SecondPrice {
+		// The winner pays the second highest bid, or the starting price if unopposed
+		amount = listing.Price
+		if len(sorted) > 1 {
+			amount = sorted[1].Amount
+		}
+	}
+	return append(results, newResult(listing, sorted[0], amount, 1))
+}
+
+func (SealedBid) ClosesOnBid() bool {
+	return false
+}
+
+// DUTCH (descending price, first bid takes it)
+type Dutch struct{}
+
+func (Dutch) ValidateBid(db *gorm.DB, listing models.Listing, bid models.Bid) *BidError {
+	var bidsCount int64
+	db.Model(&models.Bid{}).Where("listing_id = ?", listing.ID).Count(&bidsCount)
+	if bidsCount > 0 {
+		return &BidError{Code: 410, Message: "This auction is closed!"}
+	}
+	if bid.Amount.Cmp(listing.DutchCurrentPrice()) < 0 {
+		return &BidError{Code: 400, Message: "Bid amount cannot be less than the current price!"}
+	}
+	return nil
+}
+
+func (Dutch) SelectWinners(listing models.Listing, bids []models.Bid) []models.AuctionResult {
+	results := []models.AuctionResult{}
+	if len(bids) > 0 {
+		// The first bid takes it, whatever came in after
+		winningBid := bids[0]
+		for _, bid := range bids[1:] {
+			if bid.CreatedAt.Before(winningBid.CreatedAt) {
+				winningBid = bid
+			}
+		}
+		results = append(results, newResult(listing, winningBid, winningBid.Amount, 1))
+	}
+	return results
+}
+
+func (Dutch) ClosesOnBid() bool {
+	return true
+}
+
+// REVERSE (sellers bid down, lowest bid wins)
+type Reverse struct{}
+
+func (Reverse) ValidateBid(db *gorm.DB, listing models.Listing, bid models.Bid) *BidError {
+	if bid.Amount.Cmp(listing.Price) > 0 {
+		return &BidError{Code: 400, Message: "Bid amount cannot be more than the listing price!"}
+	}
+	lowestBid, exists := bidsAggregate(db, listing, "MIN")
+	if exists && bid.Amount.Cmp(lowestBid) >= 0 {
+		return &BidError{Code: 400, Message: "Bid amount must be less than the lowest bid!"}
+	}
+	return nil
+}
+
+func (Reverse) SelectWinners(listing models.Listing, bids []models.Bid) []models.AuctionResult {
+	results := []models.AuctionResult{}
+	if len(bids) > 0 {
+		sorted := sortBidsDescending(bids)
+		winningBid := sorted[len(sorted)-1]
+		for _, bid := range sorted {
+			// Earliest of the lowest bids wins
+			if bid.Amount.Equal(winningBid.Amount) {
+				winningBid = bid
+				break
+			}
+		}
+		results = append(results, newResult(listing, winningBid, winningBid.Amount, 1))
+	}
+	return results
+}
+
+func (Reverse) ClosesOnBid() bool {
+	return false
+}
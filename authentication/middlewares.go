@@ -36,6 +36,15 @@ func AuthMiddleware(c *fiber.Ctx) error {
 	return c.Next()
 }
 
+// Only lets staff through. It should be placed after AuthMiddleware
+func StaffMiddleware(c *fiber.Ctx) error {
+	user := c.Locals("user").(*models.User)
+	if user.IsStaff == nil || !*user.IsStaff {
+		return c.Status(403).JSON(utils.ErrorResponse{Message: "Staff access only!"}.Init())
+	}
+	return c.Next()
+}
+
 func parseUUID(input string) *uuid.UUID {
     uuidVal, err := uuid.FromString(input)
 	if err != nil {
@@ -44,32 +53,61 @@ func parseUUID(input string) *uuid.UUID {
     return &uuidVal
 }
 
-func ClientMiddleware(c *fiber.Ctx) error {
+// Finds the client of a request, which is the authenticated user or else the guest user
+func getClient(c *fiber.Ctx) (interface{}, *string) {
 	token := c.Get("Authorization")
 	guestId := c.Get("guestuserid")
 	db := c.Locals("db").(*gorm.DB)
 
 	if len(token) < 1 {
 		// Try for Guest
-		c.Locals("client", nil)
 		if len(guestId) > 0 {
 			guest := models.GuestUser{}
 			parsedUUID := parseUUID(guestId)
 			if parsedUUID == nil {
-				return c.Status(401).JSON(utils.ErrorResponse{Message: "Invalid type for guest id (use a uuid)"}.Init())
+				err := "Invalid type for guest id (use a uuid)"
+				return nil, &err
 			}
 			db.Take(&guest, *parsedUUID)
 			if guest.ID != uuid.Nil {
-				c.Locals("client", guest)
+				return guest, nil
 			}
 		}
-	} else {
-		// Auth User becomes client
-		user, err := getUser(c, token, db)
-		if err != nil {
-			return c.Status(401).JSON(utils.ErrorResponse{Message: *err}.Init())
-		}
-		c.Locals("client", user)
+		return nil, nil
+	}
+	// Auth User becomes client
+	user, err := getUser(c, token, db)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func ClientMiddleware(c *fiber.Ctx) error {
+	client, err := getClient(c)
+	if err != nil {
+		return c.Status(401).JSON(utils.ErrorResponse{Message: *err}.Init())
+	}
+	c.Locals("client", client)
+	return c.Next()
+}
+
+// Like ClientMiddleware, but treats requests with invalid or expired credentials as anonymous
+// instead of rejecting them, for public pages that only use the client to personalize
+func OptionalClientMiddleware(c *fiber.Ctx) error {
+	client, _ := getClient(c)
+	c.Locals("client", client)
+	return c.Next()
+}
+
+// Lets clients that can't set headers (e.g EventSource) authenticate with query params.
+// It should be placed before ClientMiddleware
+func QueryAuthMiddleware(c *fiber.Ctx) error {
+	if token := c.Query("token"); len(token) > 0 && len(c.Get("Authorization")) < 1 {
+		c.Request().Header.Set("Authorization", "Bearer "+token)
+	}
+	if guestId := c.Query("guestuserid"); len(guestId) > 0 && len(c.Get("guestuserid")) < 1 {
+		c.Request().Header.Set("guestuserid", guestId)
 	}
 	return c.Next()
 }
@@ -17,6 +17,17 @@ type Configuration struct {
 	CloudinaryCloudName       string
 	CloudinaryAPIKey          string
 	CloudinaryAPISecret       string
+	StorageBackend            string
+	LocalStorageDir           string
+	LocalStorageURL           string
+	S3Endpoint                string
+	S3Region                  string
+	S3Bucket                  string
+	S3AccessKeyID             string
+	S3SecretAccessKey         string
+	S3PublicURL               string
+	MaxUploadSizeMB           int
+	PendingUploadTTLMinutes   int
 	ProjectName               string
 	Debug                     string
 	EmailOTPExpireSeconds     int64
@@ -41,6 +52,9 @@ type Configuration struct {
 	MailSenderHost            string
 	MailSenderPort            int
 	CORSAllowedOrigins        string
+	BuyNowThresholdPercent    int
+	BaseCurrency              string
+	ExchangeRatesFile         string
 }
 
 var config *Configuration
@@ -64,11 +78,53 @@ func init() {
 	mailSenderPort, _ := strconv.Atoi(os.Getenv("MAIL_SENDER_PORT"))
 	accessTokenExpireMinutes, _ := strconv.Atoi(os.Getenv("ACCESS_TOKEN_EXPIRE_MINUTES"))
 	refreshTokenExpireMinutes, _ := strconv.Atoi(os.Getenv("REFRESH_TOKEN_EXPIRE_MINUTES"))
+	buyNowThresholdPercent, err := strconv.Atoi(os.Getenv("BUY_NOW_THRESHOLD_PERCENT"))
+	if err != nil {
+		buyNowThresholdPercent = 50
+	}
+	baseCurrency := os.Getenv("BASE_CURRENCY")
+	if baseCurrency == "" {
+		baseCurrency = "USD"
+	}
+
+	maxUploadSizeMB, err := strconv.Atoi(os.Getenv("MAX_UPLOAD_SIZE_MB"))
+	if err != nil {
+		maxUploadSizeMB = 10
+	}
+	pendingUploadTTLMinutes, err := strconv.Atoi(os.Getenv("PENDING_UPLOAD_TTL_MINUTES"))
+	if err != nil {
+		pendingUploadTTLMinutes = 24 * 60
+	}
+
+	// Files are kept on cloudinary unless another storage backend is chosen
+	storageBackend := os.Getenv("STORAGE_BACKEND")
+	if storageBackend == "" {
+		storageBackend = "cloudinary"
+	}
+	localStorageDir := os.Getenv("LOCAL_STORAGE_DIR")
+	if localStorageDir == "" {
+		localStorageDir = "media"
+	}
+	localStorageURL := os.Getenv("LOCAL_STORAGE_URL")
+	if localStorageURL == "" {
+		localStorageURL = "http://localhost:8000"
+	}
 
 	config = &Configuration{
 		CloudinaryCloudName:       os.Getenv("CLOUDINARY_CLOUD_NAME"),
 		CloudinaryAPIKey:          os.Getenv("CLOUDINARY_API_KEY"),
 		CloudinaryAPISecret:       os.Getenv("CLOUDINARY_API_SECRET"),
+		StorageBackend:            storageBackend,
+		LocalStorageDir:           localStorageDir,
+		LocalStorageURL:           localStorageURL,
+		S3Endpoint:                os.Getenv("S3_ENDPOINT"),
+		S3Region:                  os.Getenv("S3_REGION"),
+		S3Bucket:                  os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:             os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:         os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3PublicURL:               os.Getenv("S3_PUBLIC_URL"),
+		MaxUploadSizeMB:           maxUploadSizeMB,
+		PendingUploadTTLMinutes:   pendingUploadTTLMinutes,
 		ProjectName:               os.Getenv("PROJECT_NAME"),
 		Debug:                     os.Getenv("DEBUG"),
 		EmailOTPExpireSeconds:     emailOTPExpireSeconds,
@@ -93,6 +149,9 @@ func init() {
 		MailSenderHost:            os.Getenv("MAIL_SENDER_HOST"),
 		MailSenderPort:            mailSenderPort,
 		CORSAllowedOrigins:        os.Getenv("CORS_ALLOWED_ORIGINS"),
+		BuyNowThresholdPercent:    buyNowThresholdPercent,
+		BaseCurrency:              baseCurrency,
+		ExchangeRatesFile:         os.Getenv("EXCHANGE_RATES_FILE"),
 	}
 }
 
@@ -20,7 +20,8 @@ type DbInstance struct {
 
 var Database DbInstance
 
-func ConnectDb() {
+// Returns the connection string of the main database
+func Dsn() string {
 	cfg := config.GetConfig()
 
 	dsnTemplate := "host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s"
@@ -34,6 +35,11 @@ func ConnectDb() {
 		"disable",
 		"UTC",
 	)
+	return dsn
+}
+
+func ConnectDb() {
+	dsn := Dsn()
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		SkipDefaultTransaction: true,
 		PrepareStmt: true,
@@ -70,10 +76,46 @@ func ConnectDb() {
 
 		// listings
 		&models.Category{}, 
+		&models.CategoryAttribute{},
 		&models.Listing{}, 
+		&models.ListingImage{},
+		&models.ListingRevision{},
+		&models.SlugHistory{},
+		&models.ListingStat{},
+		&models.ListingViewer{},
+		&models.ListingQuestion{},
 		&models.Bid{},
 		&models.Watchlist{},
+		&models.AuctionResult{},
+		&models.Conversation{},
+		&models.Message{},
+
+		// notifications
+		&models.Notification{},
+		&models.NotificationPreference{},
+
+		// wallets
+		&models.LedgerTransaction{},
+		&models.LedgerEntry{},
+
+		// currencies
+		&models.ExchangeRate{},
+
+		// saved searches
+		&models.SavedSearch{},
 	)
+	if err := models.SetupBidIndexes(db); err != nil {
+		log.Fatal("Failed to set up bid indexes: " + err.Error())
+	}
+	// Listings take their currency from the config, so the old hardcoded column default goes
+	if err := db.Exec("ALTER TABLE listings ALTER COLUMN currency DROP DEFAULT").Error; err != nil {
+		log.Fatal("Failed to drop the listing currency default: " + err.Error())
+	}
+	if err := models.SetupListingSearch(db); err != nil {
+		log.Fatal("Failed to set up listing search: " + err.Error())
+	}
+	models.SetupListingImages(db)
+	models.SetupFileFolders(db)
 
 	Database = DbInstance{Db: db}
 }
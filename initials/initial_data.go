@@ -11,6 +11,7 @@ import (
 	"github.com/gosimple/slug"
 
 
+	"github.com/kayprogrammer/bidout-auction-v7/config"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
 	"github.com/kayprogrammer/bidout-auction-v7/utils"
 	uuid "github.com/satori/go.uuid"
@@ -153,7 +154,7 @@ func uploadListingImages(images []models.File) {
 			log.Println(err)
 		}
 		defer file.Close()
-		utils.UploadImage(file, publicID, "listings")
+		utils.UploadImage(file, publicID, "listings", images[i].ResourceType)
 	}
 }
 
@@ -195,13 +196,24 @@ func createListings(db *gorm.DB, auctioneerId uuid.UUID, categories []models.Cat
 		db.Create(&listings)
 
 		// Upload Images
-		log.Println("Uploading Images to cloudinary....")
+		log.Println("Uploading Images....")
 		uploadListingImages(images)
 		log.Println("Images uploaded....")
 
 	}
 }
 
+func createExchangeRates(db *gorm.DB) {
+	// Import exchange rates from a file if one is configured
+	ratesFile := config.GetConfig().ExchangeRatesFile
+	if ratesFile == "" {
+		return
+	}
+	if err := models.ImportExchangeRates(db, ratesFile); err != nil {
+		log.Println("Exchange rates import failed: ", err)
+	}
+}
+
 func CreateInitialData(db *gorm.DB) {
 	log.Println("Creating Initial Data....")
 	createSuperUser(db)
@@ -210,5 +222,6 @@ func CreateInitialData(db *gorm.DB) {
 	createReviews(db, reviewer.ID)
 	categories := createCategories(db)
 	createListings(db, auctioneer.ID, categories)
+	createExchangeRates(db)
 	log.Println("Initial Data Created....")
 }
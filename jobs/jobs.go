@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/analytics"
+	"github.com/kayprogrammer/bidout-auction-v7/auctions"
+)
+
+// Runs a job at a fixed interval in the background
+func every(interval time.Duration, name string, job func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			func() {
+				// Don't let a failing job take the server down
+				defer func() {
+					if r := recover(); r != nil {
+						log.Printf("Job %s failed: %v", name, r)
+					}
+				}()
+				job()
+			}()
+		}
+	}()
+}
+
+// Starts the background jobs that keep auctions moving
+func Start(db *gorm.DB) {
+	every(time.Minute, "finalize-ended-listings", func() { auctions.FinalizeEndedListings(db) })
+	every(5*time.Minute, "ending-soon-reminders", func() { sendEndingSoonReminders(db) })
+	every(time.Minute, "auction-live-notifications", func() { sendAuctionLiveNotifications(db) })
+	every(time.Hour, "saved-search-digests", func() { sendSavedSearchDigests(db) })
+	every(time.Minute, "flush-listing-stats", func() { analytics.Flush(db) })
+	every(15*time.Minute, "sweep-pending-uploads", func() { SweepPendingUploads(db) })
+}
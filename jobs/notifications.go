@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/senders"
+)
+
+// Reminds watchers of listings closing within the next hour. Reminders are
+// de-duplicated per listing, so overlapping runs don't send twice
+func sendEndingSoonReminders(db *gorm.DB) {
+	now := time.Now().UTC()
+	closingListings := db.Model(&models.Listing{}).Select("id").Where(
+		"state = ? AND active = ? AND finalized_at IS NULL AND closing_date > ? AND closing_date <= ?", models.ListingStatePublished, true, now, now.Add(time.Hour),
+	)
+	watchlists := []models.Watchlist{}
+	db.Preload("User").Preload("Listing").Where("user_id IS NOT NULL AND listing_id IN (?)", closingListings).Find(&watchlists)
+	for _, watchlist := range watchlists {
+		senders.NotifyEndingSoon("normal", db, *watchlist.User, watchlist.Listing)
+	}
+}
+
+// Tells watchers of scheduled listings that bidding has opened. Only listings that
+// started within the last hour are considered, so a long outage doesn't flood inboxes
+func sendAuctionLiveNotifications(db *gorm.DB) {
+	now := time.Now().UTC()
+	startedListings := db.Model(&models.Listing{}).Select("id").Where(
+		"state = ? AND active = ? AND finalized_at IS NULL AND starts_at <= ? AND starts_at > ? AND closing_date > ?", models.ListingStatePublished, true, now, now.Add(-time.Hour), now,
+	)
+	watchlists := []models.Watchlist{}
+	db.Preload("User").Preload("Listing").Where("user_id IS NOT NULL AND listing_id IN (?)", startedListings).Find(&watchlists)
+	for _, watchlist := range watchlists {
+		senders.NotifyAuctionLive("normal", db, *watchlist.User, watchlist.Listing)
+	}
+}
+
+// Sends a daily digest for every digest saved search with new matching listings
+func sendSavedSearchDigests(db *gorm.DB) {
+	now := time.Now().UTC()
+	searches := []models.SavedSearch{}
+	db.Preload("User").Where(
+		"frequency = ? AND (last_alerted_at IS NULL OR last_alerted_at <= ?)", models.AlertDigest, now.Add(-24*time.Hour),
+	).Find(&searches)
+	for _, search := range searches {
+		since := search.CreatedAt
+		if search.LastAlertedAt != nil {
+			since = *search.LastAlertedAt
+		}
+		if listings := search.NewListings(db, since); len(listings) > 0 {
+			senders.NotifySavedSearchDigest("normal", db, search, listings)
+		}
+		db.Model(&search).UpdateColumn("last_alerted_at", now)
+	}
+}
@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/config"
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+// Deletes files whose upload was never confirmed, along with whatever got uploaded for them.
+// A listing's cover is only deleted once another ready image can take its place
+func SweepPendingUploads(db *gorm.DB) {
+	ttl := time.Duration(config.GetConfig().PendingUploadTTLMinutes) * time.Minute
+	for _, file := range models.ExpiredUploads(db, ttl) {
+		if !models.ReplaceListingCover(db, file) {
+			continue
+		}
+		// Gallery images go with it while avatars and attachments are cleared
+		models.DeleteFile(db, file)
+	}
+}
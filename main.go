@@ -2,10 +2,17 @@ package main
 
 import (
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/kayprogrammer/bidout-auction-v7/analytics"
 	"github.com/kayprogrammer/bidout-auction-v7/database"
+	"github.com/kayprogrammer/bidout-auction-v7/jobs"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
 	"github.com/kayprogrammer/bidout-auction-v7/routes"
 	"github.com/kayprogrammer/bidout-auction-v7/initials"
 	"github.com/kayprogrammer/bidout-auction-v7/config"
@@ -34,6 +41,8 @@ func main() {
 	database.ConnectDb()
 	db := database.Database.Db
 	initials.CreateInitialData(db)
+	jobs.Start(db)
+	realtime.Listen(database.Dsn())
 
 	app := fiber.New()
 
@@ -43,7 +52,7 @@ func main() {
 	// CORS config
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: cfg.CORSAllowedOrigins,
-		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, Guestuserid, Access-Control-Allow-Origin, Content-Disposition",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, Guestuserid, Accept-Currency, Access-Control-Allow-Origin, Content-Disposition",
 		AllowCredentials: true,
 		AllowMethods: "GET, POST, PUT, PATCH, DELETE, OPTIONS",
 	}))
@@ -65,5 +74,16 @@ func main() {
 
 	// Register routes
 	routes.SetupRoutes(app)
-	log.Fatal(app.Listen(":8000"))
+
+	// Stop gracefully so the listing stats still buffered in memory can be saved
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		<-quit
+		app.Shutdown()
+	}()
+	if err := app.Listen(":8000"); err != nil {
+		log.Fatal(err)
+	}
+	analytics.Flush(db)
 }
\ No newline at end of file
@@ -48,7 +48,9 @@ func (user User) GetAvatarUrl(db *gorm.DB) *string {
 func (user User) GetAvatarUploadUrl(db *gorm.DB) *utils.SignatureFormat {
 	avatarId := user.AvatarId
 	if avatarId != nil {
-		uploadData := utils.GenerateFileSignature(avatarId.String(), "avatars")
+		avatar := File{}
+		db.Take(&avatar, avatarId)
+		uploadData := utils.GenerateFileSignature(avatarId.String(), "avatars", avatar.ResourceType)
 		return &uploadData
 	}
 	return nil
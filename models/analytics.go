@@ -0,0 +1,167 @@
+package models
+
+import (
+	"time"
+
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LISTING STAT (the number of times a listing was shown in lists and opened on a day)
+type ListingStat struct {
+	BaseModel
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:listing_id_day"`
+	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
+	Day					time.Time			`json:"-" gorm:"type:date;not null;index:,unique,composite:listing_id_day"`
+	Impressions			int64				`json:"-" gorm:"not null;default:0"`
+	Views				int64				`json:"-" gorm:"not null;default:0"`
+}
+
+// LISTING VIEWER (a client that opened a listing on a day, for counting unique viewers)
+type ListingViewer struct {
+	BaseModel
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:listing_id_day_viewer"`
+	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
+	Day					time.Time			`json:"-" gorm:"type:date;not null;index:,unique,composite:listing_id_day_viewer"`
+	ViewerKey			string				`json:"-" gorm:"type:varchar(64);not null;index:,unique,composite:listing_id_day_viewer"`
+}
+
+// Adds counts to a listing's stats for a day
+func AddListingStats(db *gorm.DB, listingId uuid.UUID, day time.Time, impressions int64, views int64, viewerKeys []string) {
+	stat := ListingStat{ListingId: listingId, Day: day, Impressions: impressions, Views: views}
+	db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "listing_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"impressions": gorm.Expr("listing_stats.impressions + ?", impressions),
+			"views":       gorm.Expr("listing_stats.views + ?", views),
+			"updated_at":  time.Now().UTC(),
+		}),
+	}).Create(&stat)
+
+	if len(viewerKeys) > 0 {
+		viewers := []ListingViewer{}
+		for _, viewerKey := range viewerKeys {
+			viewers = append(viewers, ListingViewer{ListingId: listingId, Day: day, ViewerKey: viewerKey})
+		}
+		db.Clauses(clause.OnConflict{DoNothing: true}).Create(&viewers)
+	}
+}
+
+type DailyListingAnalytics struct {
+	Date				string				`json:"date" example:"2024-01-31"`
+	Impressions			int64				`json:"impressions" example:"120"`
+	Views				int64				`json:"views" example:"40"`
+	UniqueViewers		int64				`json:"unique_viewers" example:"25"`
+	Bids				int64				`json:"bids" example:"3"`
+}
+
+type ListingAnalytics struct {
+	Listing				string					`json:"listing" example:"listing_slug"`
+	Name				string					`json:"name" example:"Product name"`
+	Impressions			int64					`json:"impressions" example:"1200"`
+	Views				int64					`json:"views" example:"400"`
+	UniqueViewers		int64					`json:"unique_viewers" example:"250"`
+	Watchers			int64					`json:"watchers" example:"12"`
+	Bids				int64					`json:"bids" example:"30"`
+	Bidders				int64					`json:"bidders" example:"10"`
+	ConversionRate		float64					`json:"conversion_rate" example:"0.04"`	// bidders per unique viewer
+	Days				[]DailyListingAnalytics	`json:"days"`
+}
+
+type listingDayCount struct {
+	ListingId			uuid.UUID
+	Day					time.Time
+	Impressions			int64
+	Views				int64
+	Count				int64
+}
+
+type listingCount struct {
+	ListingId			uuid.UUID
+	Count				int64
+}
+
+// Returns the analytics of listings for each day since a date (in UTC)
+func GetListingAnalytics(db *gorm.DB, listings []Listing, since time.Time) []ListingAnalytics {
+	since = since.UTC().Truncate(24 * time.Hour)
+	listingIds := []uuid.UUID{}
+	for _, listing := range listings {
+		listingIds = append(listingIds, listing.ID)
+	}
+	if len(listingIds) == 0 {
+		return []ListingAnalytics{}
+	}
+
+	stats := []listingDayCount{}
+	db.Model(&ListingStat{}).Select("listing_id, day, impressions, views").Where("listing_id IN ? AND day >= ?", listingIds, since).Scan(&stats)
+	dailyViewers := []listingDayCount{}
+	db.Model(&ListingViewer{}).Select("listing_id, day, COUNT(*) AS count").Where("listing_id IN ? AND day >= ?", listingIds, since).Group("listing_id, day").Scan(&dailyViewers)
+	uniqueViewers := []listingCount{}
+	db.Model(&ListingViewer{}).Select("listing_id, COUNT(DISTINCT viewer_key) AS count").Where("listing_id IN ? AND day >= ?", listingIds, since).Group("listing_id").Scan(&uniqueViewers)
+	dailyBids := []listingDayCount{}
+	db.Model(&Bid{}).Select("listing_id, DATE(created_at) AS day, COUNT(*) AS count").Where("listing_id IN ? AND created_at >= ?", listingIds, since).Group("listing_id, DATE(created_at)").Scan(&dailyBids)
+	bidders := []listingCount{}
+	db.Model(&Bid{}).Select("listing_id, COUNT(DISTINCT user_id) AS count").Where("listing_id IN ? AND created_at >= ?", listingIds, since).Group("listing_id").Scan(&bidders)
+	watchers := []listingCount{}
+	db.Model(&Watchlist{}).Select("listing_id, COUNT(*) AS count").Where("listing_id IN ?", listingIds).Group("listing_id").Scan(&watchers)
+
+	// Lay out a row for every day so gaps show up as zeros
+	dates := []string{}
+	for day := since; !day.After(time.Now().UTC()); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+	analytics := map[uuid.UUID]*ListingAnalytics{}
+	days := map[uuid.UUID]map[string]*DailyListingAnalytics{}
+	results := []ListingAnalytics{}
+	for _, listing := range listings {
+		listingAnalytics := &ListingAnalytics{Listing: *listing.Slug, Name: listing.Name, Days: []DailyListingAnalytics{}}
+		analytics[listing.ID] = listingAnalytics
+		days[listing.ID] = map[string]*DailyListingAnalytics{}
+		for _, date := range dates {
+			days[listing.ID][date] = &DailyListingAnalytics{Date: date}
+		}
+	}
+	dayOf := func(count listingDayCount) *DailyListingAnalytics {
+		return days[count.ListingId][count.Day.UTC().Format("2006-01-02")]
+	}
+	for _, stat := range stats {
+		if day := dayOf(stat); day != nil {
+			day.Impressions, day.Views = stat.Impressions, stat.Views
+		}
+		analytics[stat.ListingId].Impressions += stat.Impressions
+		analytics[stat.ListingId].Views += stat.Views
+	}
+	for _, count := range dailyViewers {
+		if day := dayOf(count); day != nil {
+			day.UniqueViewers = count.Count
+		}
+	}
+	for _, count := range dailyBids {
+		if day := dayOf(count); day != nil {
+			day.Bids = count.Count
+		}
+		analytics[count.ListingId].Bids += count.Count
+	}
+	for _, count := range uniqueViewers {
+		analytics[count.ListingId].UniqueViewers = count.Count
+	}
+	for _, count := range bidders {
+		analytics[count.ListingId].Bidders = count.Count
+	}
+	for _, count := range watchers {
+		analytics[count.ListingId].Watchers = count.Count
+	}
+
+	for _, listing := range listings {
+		listingAnalytics := analytics[listing.ID]
+		if listingAnalytics.UniqueViewers > 0 {
+			listingAnalytics.ConversionRate = float64(listingAnalytics.Bidders) / float64(listingAnalytics.UniqueViewers)
+		}
+		for _, date := range dates {
+			listingAnalytics.Days = append(listingAnalytics.Days, *days[listing.ID][date])
+		}
+		results = append(results, *listingAnalytics)
+	}
+	return results
+}
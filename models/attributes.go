@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Attribute types
+const (
+	AttributeEnum				= "enum"
+	AttributeNumber				= "number"
+	AttributeText				= "text"
+	AttributeBool				= "bool"
+)
+
+const maxAttributeTextLength = 255
+
+// CATEGORY ATTRIBUTE
+type CategoryAttribute struct {
+	BaseModel
+	CategoryId			uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:category_id_name"`
+	Category			Category			`json:"-" gorm:"foreignKey:CategoryId;constraint:OnDelete:CASCADE;not null;"`
+	Name				string				`json:"name" gorm:"type:varchar(50);not null;index:,unique,composite:category_id_name" example:"mileage"`
+	Label				string				`json:"label" gorm:"type:varchar(100);not null" example:"Mileage (km)"`
+	Type				string				`json:"type" gorm:"type:varchar(10);not null" example:"number"`
+	Options				[]string			`json:"options,omitempty" gorm:"serializer:json" example:"64GB,128GB,256GB"`
+	Required			bool				`json:"required" gorm:"default:false;not null" example:"true"`
+	DisplayOrder		int					`json:"display_order" gorm:"default:0;not null" example:"0"`
+}
+
+// Returns the ids of a category and all the categories above it
+func CategoryAncestorIds(db *gorm.DB, categoryId uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{}
+	db.Raw(`WITH RECURSIVE tree AS (
+			SELECT id, parent_id FROM categories WHERE id = ?
+			UNION
+			SELECT categories.id, categories.parent_id FROM categories JOIN tree ON categories.id = tree.parent_id
+		) SELECT id FROM tree`, categoryId).Scan(&ids)
+	return ids
+}
+
+// Returns the attributes listings of a category can have, including those inherited from
+// its parent categories. Uncategorized listings have none
+func CategoryAttributes(db *gorm.DB, categoryId *uuid.UUID) []CategoryAttribute {
+	attributes := []CategoryAttribute{}
+	if categoryId == nil {
+		return attributes
+	}
+	db.Where("category_id IN ?", CategoryAncestorIds(db, *categoryId)).Order("display_order ASC, name ASC").Find(&attributes)
+	return attributes
+}
+
+// Returns the attributes defined on the categories below a category, which inherit its own
+func SubcategoryAttributes(db *gorm.DB, categoryId uuid.UUID) []CategoryAttribute {
+	attributes := []CategoryAttribute{}
+	db.Raw(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE parent_id = ?
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		) SELECT category_attributes.* FROM category_attributes JOIN tree ON category_attributes.category_id = tree.id`, categoryId).Scan(&attributes)
+	return attributes
+}
+
+// Replaces the attributes defined directly on a category
+func SetCategoryAttributes(db *gorm.DB, categoryId uuid.UUID, attributes []CategoryAttribute) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("category_id = ?", categoryId).Delete(&CategoryAttribute{}).Error; err != nil {
+			return err
+		}
+		for i := range attributes {
+			attributes[i].CategoryId = categoryId
+			if err := tx.Create(&attributes[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Checks a single value against the attribute's type. JSON numbers arrive as float64
+func (attribute CategoryAttribute) validateValue(value interface{}) string {
+	switch attribute.Type {
+	case AttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return "Must be a number!"
+		}
+	case AttributeBool:
+		if _, ok := value.(bool); !ok {
+			return "Must be true or false!"
+		}
+	case AttributeText:
+		text, ok := value.(string)
+		if !ok {
+			return "Must be text!"
+		}
+		if len(text) > maxAttributeTextLength {
+			return fmt.Sprintf("%d characters max", maxAttributeTextLength)
+		}
+	case AttributeEnum:
+		option, _ := value.(string)
+		for _, validOption := range attribute.Options {
+			if option == validOption {
+				return ""
+			}
+		}
+		return fmt.Sprintf("Must be one of: %s", strings.Join(attribute.Options, " "))
+	}
+	return ""
+}
+
+// Validates listing attribute values against the attributes of its category. Returns the
+// values without empty entries, or the errors keyed by 'attributes.<name>'
+func ValidateListingAttributes(attributes []CategoryAttribute, values map[string]interface{}) (map[string]interface{}, map[string]string) {
+	cleanedValues := map[string]interface{}{}
+	errors := map[string]string{}
+	attributesByName := map[string]CategoryAttribute{}
+	for _, attribute := range attributes {
+		attributesByName[attribute.Name] = attribute
+	}
+
+	for name, value := range values {
+		if value == nil {
+			continue
+		}
+		attribute, ok := attributesByName[name]
+		if !ok {
+			errors["attributes."+name] = "Unknown attribute!"
+		} else if errMsg := attribute.validateValue(value); errMsg != "" {
+			errors["attributes."+name] = errMsg
+		} else {
+			cleanedValues[name] = value
+		}
+	}
+	for _, attribute := range attributes {
+		if _, ok := cleanedValues[attribute.Name]; !ok && attribute.Required && errors["attributes."+attribute.Name] == "" {
+			errors["attributes."+attribute.Name] = "This field is required."
+		}
+	}
+	if len(errors) > 0 {
+		return nil, errors
+	}
+	return cleanedValues, nil
+}
+
+// Converts attribute values given as text (e.g cells of a CSV file) to the types of the
+// attributes. Values that can't be converted stay text, so validation reports them
+func ParseAttributeValues(attributes []CategoryAttribute, values map[string]string) map[string]interface{} {
+	attributeTypes := map[string]string{}
+	for _, attribute := range attributes {
+		attributeTypes[attribute.Name] = attribute.Type
+	}
+	parsedValues := map[string]interface{}{}
+	for name, value := range values {
+		parsedValues[name] = value
+		switch attributeTypes[name] {
+		case AttributeNumber:
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				parsedValues[name] = number
+			}
+		case AttributeBool:
+			if boolean, err := strconv.ParseBool(value); err == nil {
+				parsedValues[name] = boolean
+			}
+		}
+	}
+	return parsedValues
+}
+
+// Attribute filter operators
+const (
+	AttributeEquals				= "="
+	AttributeAtLeast			= ">="
+	AttributeAtMost				= "<="
+)
+
+// Filters listings by an attribute value. Range operators only match number attributes
+type AttributeFilter struct {
+	Name				string
+	Operator			string
+	Value				string
+}
+
+func (filter AttributeFilter) apply(db *gorm.DB) *gorm.DB {
+	switch filter.Operator {
+	case AttributeAtLeast, AttributeAtMost:
+		return db.Where(
+			"CASE WHEN jsonb_typeof(listings.attributes -> ?) = 'number' THEN (listings.attributes ->> ?)::numeric END "+filter.Operator+" ?::numeric",
+			filter.Name, filter.Name, filter.Value,
+		)
+	}
+	return db.Where("listings.attributes ->> ? = ?", filter.Name, filter.Value)
+}
@@ -15,6 +15,11 @@ type BaseModel struct {
 type File struct {
 	BaseModel
 	ResourceType		string 		`json:"resource_type" gorm:"not null"`
+	Folder				string		`json:"-" gorm:"type:varchar(20);default:listings;not null"`
+	UploaderId			*uuid.UUID	`json:"-" gorm:"null;index"`
+	Status				string		`json:"status" gorm:"type:varchar(20);default:ready;not null;index"`
+	// The listing cover this upload takes the place of once it's confirmed
+	ReplacesId			*uuid.UUID	`json:"-" gorm:"null"`
 }
 
 type ShortUserData struct {
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+func (c Category) Init() Category {
+	if c.ParentObj != nil {
+		c.Parent = c.ParentObj.Slug
+	}
+	return c
+}
+
+// Orders categories the way they should be displayed
+func OrderCategories(db *gorm.DB) *gorm.DB {
+	return db.Order("display_order ASC, name ASC")
+}
+
+// Returns the root categories with their subcategories nested under them
+func CategoryTree(db *gorm.DB) []Category {
+	categories := []Category{}
+	db.Scopes(OrderCategories).Find(&categories)
+
+	slugs := map[uuid.UUID]*string{}
+	children := map[uuid.UUID][]Category{}
+	for _, category := range categories {
+		slugs[category.ID] = category.Slug
+		parentId := uuid.Nil
+		if category.ParentId != nil {
+			parentId = *category.ParentId
+		}
+		children[parentId] = append(children[parentId], category)
+	}
+
+	var build func(parentId uuid.UUID) []Category
+	build = func(parentId uuid.UUID) []Category {
+		nodes := children[parentId]
+		for i := range nodes {
+			nodes[i].Parent = slugs[parentId]
+			nodes[i].Children = build(nodes[i].ID)
+		}
+		return nodes
+	}
+	tree := build(uuid.Nil)
+	if tree == nil {
+		tree = []Category{}
+	}
+	return tree
+}
+
+// Returns the ids of a category and all the categories below it
+func CategoryDescendantIds(db *gorm.DB, categoryId uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{}
+	db.Raw(`WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		) SELECT id FROM tree`, categoryId).Scan(&ids)
+	return ids
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// CONVERSATION (a private thread between the auctioneer of a listing and one of its winners)
+type Conversation struct {
+	BaseModel
+	Identifier			uuid.UUID			`json:"id" gorm:"-"`
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:listing_id_buyer_id"`
+	ListingObj			Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
+	Listing				string				`json:"listing" gorm:"-" example:"listing_slug"`
+
+	BuyerId				uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:listing_id_buyer_id"`
+	BuyerObj			User				`json:"-" gorm:"foreignKey:BuyerId;constraint:OnDelete:CASCADE;not null;"`
+	Buyer				ShortUserData		`json:"buyer" gorm:"-"`
+	SellerId			uuid.UUID			`json:"-" gorm:"not null;index"`
+	SellerObj			User				`json:"-" gorm:"foreignKey:SellerId;constraint:OnDelete:CASCADE;not null;"`
+	Seller				ShortUserData		`json:"seller" gorm:"-"`
+
+	LastMessageAt		*time.Time			`json:"last_message_at" gorm:"null"`
+	UnreadCount			int64				`json:"unread_count" gorm:"-"`
+}
+
+// Fills in the display fields. Unread messages are counted for the given user
+func (conversation Conversation) Init(db *gorm.DB, userId uuid.UUID) Conversation {
+	conversation.Identifier = conversation.ID
+	if conversation.ListingObj.Slug != nil {
+		conversation.Listing = *conversation.ListingObj.Slug
+	}
+	conversation.Buyer = ShortUserData{ID: conversation.BuyerObj.ID, Name: conversation.BuyerObj.FullName(), Avatar: conversation.BuyerObj.GetAvatarUrl(db)}
+	conversation.Seller = ShortUserData{ID: conversation.SellerObj.ID, Name: conversation.SellerObj.FullName(), Avatar: conversation.SellerObj.GetAvatarUrl(db)}
+	db.Model(&Message{}).Where("conversation_id = ? AND sender_id != ? AND read_at IS NULL", conversation.ID, userId).Count(&conversation.UnreadCount)
+	return conversation
+}
+
+// Checks if a user is one of the two people in the conversation
+func (conversation Conversation) HasParticipant(userId uuid.UUID) bool {
+	return conversation.BuyerId == userId || conversation.SellerId == userId
+}
+
+// Checks if a user won the listing, either by bidding or buying it outright
+func IsListingWinner(db *gorm.DB, listingId uuid.UUID, userId uuid.UUID) bool {
+	var resultsCount int64
+	db.Model(&AuctionResult{}).Where("listing_id = ? AND winner_id = ?", listingId, userId).Count(&resultsCount)
+	return resultsCount > 0
+}
+
+// Returns the number of messages sent to a user that they haven't read, across all conversations
+func UnreadMessagesCount(db *gorm.DB, userId uuid.UUID) int64 {
+	var unreadCount int64
+	conversationIds := db.Model(&Conversation{}).Select("id").Where("buyer_id = ? OR seller_id = ?", userId, userId)
+	db.Model(&Message{}).Where("conversation_id IN (?) AND sender_id != ? AND read_at IS NULL", conversationIds, userId).Count(&unreadCount)
+	return unreadCount
+}
+
+// MESSAGE
+type Message struct {
+	BaseModel
+	Identifier			uuid.UUID			`json:"id" gorm:"-"`
+	ConversationId		uuid.UUID			`json:"-" gorm:"not null;index"`
+	Conversation		Conversation		`json:"-" gorm:"foreignKey:ConversationId;constraint:OnDelete:CASCADE;not null;"`
+	SenderId			uuid.UUID			`json:"-" gorm:"not null"`
+	SenderObj			User				`json:"-" gorm:"foreignKey:SenderId;constraint:OnDelete:CASCADE;not null;"`
+	Sender				ShortUserData		`json:"sender" gorm:"-"`
+
+	Body				string				`json:"body" gorm:"type:varchar(2000);not null" example:"I can pay by transfer today."`
+	AttachmentId		*uuid.UUID			`json:"-" gorm:"null"`
+	Attachment			*File				`json:"-" gorm:"foreignKey:AttachmentId;constraint:OnDelete:SET NULL;null;"`
+	AttachmentUrl		*string				`json:"attachment_url" gorm:"-"`
+	SentAt				time.Time			`json:"sent_at" gorm:"-"`
+	ReadAt				*time.Time			`json:"read_at" gorm:"null"`
+}
+
+func (message Message) Init(db *gorm.DB) Message {
+	message.Identifier = message.ID
+	message.SentAt = message.CreatedAt
+	message.Sender = ShortUserData{ID: message.SenderObj.ID, Name: message.SenderObj.FullName(), Avatar: message.SenderObj.GetAvatarUrl(db)}
+	if message.Attachment != nil {
+		url := utils.GenerateFileUrl(message.Attachment.ID.String(), "messages", message.Attachment.ResourceType)
+		message.AttachmentUrl = &url
+	}
+	return message
+}
+
+func (message Message) GetAttachmentUploadData() *utils.SignatureFormat {
+	if message.Attachment == nil {
+		return nil
+	}
+	uploadData := utils.GenerateFileSignature(message.Attachment.ID.String(), "messages", message.Attachment.ResourceType)
+	return &uploadData
+}
+
+// Marks the messages sent to a user in a conversation as read
+func MarkMessagesRead(db *gorm.DB, conversationId uuid.UUID, userId uuid.UUID) {
+	db.Model(&Message{}).Where("conversation_id = ? AND sender_id != ? AND read_at IS NULL", conversationId, userId).Update("read_at", time.Now().UTC())
+}
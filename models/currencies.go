@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/config"
+)
+
+// EXCHANGE RATE (how many units of a currency one unit of the base currency buys)
+type ExchangeRate struct {
+	BaseModel
+	Currency			string				`json:"currency" gorm:"type:varchar(3);unique;not null" example:"EUR"`
+	Rate				decimal.Decimal		`json:"rate" gorm:"not null" example:"0.92"`
+}
+
+// Prices shown in the currency a client asked for
+type DisplayPrices struct {
+	Currency			string				`json:"currency" example:"EUR"`
+	Price				decimal.Decimal		`json:"price" example:"920.00"`
+	HighestBid			decimal.Decimal		`json:"highest_bid" example:"0.00"`
+	CurrentPrice		*decimal.Decimal	`json:"current_price,omitempty"`
+	BuyNowPrice			*decimal.Decimal	`json:"buy_now_price,omitempty"`
+}
+
+// Returns the currency every exchange rate is relative to
+func BaseCurrency() string {
+	return config.GetConfig().BaseCurrency
+}
+
+func exchangeRate(db *gorm.DB, currency string) (decimal.Decimal, bool) {
+	if currency == BaseCurrency() {
+		return decimal.NewFromInt(1), true
+	}
+	rate := ExchangeRate{}
+	db.Take(&rate, ExchangeRate{Currency: currency})
+	return rate.Rate, rate.Rate.IsPositive()
+}
+
+// Checks if amounts can be converted to and from the currency
+func CurrencySupported(db *gorm.DB, currency string) bool {
+	_, ok := exchangeRate(db, currency)
+	return ok
+}
+
+// Converts an amount between two currencies through the base currency
+func ConvertAmount(db *gorm.DB, amount decimal.Decimal, from string, to string) (decimal.Decimal, bool) {
+	if from == to {
+		return amount, true
+	}
+	fromRate, fromOk := exchangeRate(db, from)
+	toRate, toOk := exchangeRate(db, to)
+	if !fromOk || !toOk {
+		return amount, false
+	}
+	return amount.Div(fromRate).Mul(toRate).Round(2), true
+}
+
+// Creates or updates exchange rates keyed by currency code
+func SetExchangeRates(db *gorm.DB, rates map[string]decimal.Decimal) error {
+	exchangeRates := []ExchangeRate{}
+	for currency, rate := range rates {
+		exchangeRates = append(exchangeRates, ExchangeRate{Currency: strings.ToUpper(currency), Rate: rate})
+	}
+	if len(exchangeRates) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"rate": gorm.Expr("excluded.rate"), "updated_at": time.Now()}),
+	}).Create(&exchangeRates).Error
+}
+
+// Loads exchange rates from a JSON file shaped like {"EUR": 0.92, "GBP": 0.79}
+func ImportExchangeRates(db *gorm.DB, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rates := map[string]decimal.Decimal{}
+	if err := json.Unmarshal(content, &rates); err != nil {
+		return err
+	}
+	return SetExchangeRates(db, rates)
+}
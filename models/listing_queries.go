@@ -0,0 +1,181 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Listing sorts
+const (
+	SortNewest				= "newest"
+	SortEndingSoon			= "ending_soon"
+	SortPriceAsc			= "price_asc"
+	SortPriceDesc			= "price_desc"
+	SortMostBids			= "most_bids"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const bidsCountExpr = "(SELECT COUNT(*) FROM bids WHERE bids.listing_id = listings.id)"
+
+// ListingQuery filters, sorts and pages through listings in SQL. Pages are keyset based:
+// the cursor holds the sort value and ID of the last listing of the previous page
+type ListingQuery struct {
+	CategoryIds			[]uuid.UUID			// a category and its descendants
+	Uncategorized		bool
+	MinPrice			*decimal.Decimal
+	MaxPrice			*decimal.Decimal
+	Status				string
+	States				[]string
+	Archived			bool
+	AuctioneerId		*uuid.UUID
+	Attributes			[]AttributeFilter
+	Sort				string
+	Cursor				string
+	Limit				int
+}
+
+type listingCursor struct {
+	Sort				string				`json:"s"`
+	Value				string				`json:"v"`
+	ID					uuid.UUID			`json:"id"`
+}
+
+// Returns the SQL expression listings are ordered by and whether the order is descending
+func (query ListingQuery) sortColumn() (string, bool) {
+	switch query.Sort {
+	case SortEndingSoon:
+		return "listings.closing_date", false
+	case SortPriceAsc:
+		return "listings.price", false
+	case SortPriceDesc:
+		return "listings.price", true
+	case SortMostBids:
+		return bidsCountExpr, true
+	default:
+		return "listings.created_at", true
+	}
+}
+
+// Returns the sort value of a listing as stored in a cursor
+func (query ListingQuery) sortValue(listing Listing) string {
+	switch query.Sort {
+	case SortEndingSoon:
+		return listing.ClosingDate.UTC().Format(time.RFC3339Nano)
+	case SortPriceAsc, SortPriceDesc:
+		return listing.Price.String()
+	case SortMostBids:
+		return strconv.Itoa(listing.GetBidsCount())
+	default:
+		return listing.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+func (query ListingQuery) encodeCursor(listing Listing) string {
+	content, _ := json.Marshal(listingCursor{Sort: query.Sort, Value: query.sortValue(listing), ID: listing.ID})
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// Decodes the cursor into the listing ID and a typed sort value
+func (query ListingQuery) decodeCursor() (uuid.UUID, interface{}, error) {
+	content, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return uuid.Nil, nil, ErrInvalidCursor
+	}
+	cursor := listingCursor{}
+	if err := json.Unmarshal(content, &cursor); err != nil || cursor.Sort != query.Sort {
+		return uuid.Nil, nil, ErrInvalidCursor
+	}
+	var value interface{}
+	switch query.Sort {
+	case SortPriceAsc, SortPriceDesc:
+		value, err = decimal.NewFromString(cursor.Value)
+	case SortMostBids:
+		value, err = strconv.ParseInt(cursor.Value, 10, 64)
+	default:
+		value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	}
+	if err != nil {
+		return uuid.Nil, nil, ErrInvalidCursor
+	}
+	return cursor.ID, value, nil
+}
+
+func (query ListingQuery) filter(db *gorm.DB) *gorm.DB {
+	db = db.Model(&Listing{}).Scopes(FilterByStatus(query.Status))
+	if query.Archived {
+		db = db.Unscoped().Where("listings.deleted_at IS NOT NULL")
+	}
+	if len(query.States) > 0 {
+		db = db.Where("listings.state IN ?", query.States)
+	}
+	if query.Uncategorized {
+		db = db.Where("listings.category_id IS NULL")
+	} else if len(query.CategoryIds) > 0 {
+		db = db.Where("listings.category_id IN ?", query.CategoryIds)
+	}
+	if query.MinPrice != nil {
+		db = db.Where("listings.price >= ?", *query.MinPrice)
+	}
+	if query.MaxPrice != nil {
+		db = db.Where("listings.price <= ?", *query.MaxPrice)
+	}
+	if query.AuctioneerId != nil {
+		db = db.Where("listings.auctioneer_id = ?", *query.AuctioneerId)
+	}
+	for _, attributeFilter := range query.Attributes {
+		db = attributeFilter.apply(db)
+	}
+	return db
+}
+
+// Returns the number of listings matching the filters, regardless of the page
+func (query ListingQuery) Count(db *gorm.DB) int64 {
+	var total int64
+	query.filter(db).Count(&total)
+	return total
+}
+
+// Returns a page of listings and the cursor of the next page (nil on the last page)
+func (query ListingQuery) Find(db *gorm.DB) ([]Listing, *string, error) {
+	column, descending := query.sortColumn()
+	operator, direction := ">", "ASC"
+	if descending {
+		operator, direction = "<", "DESC"
+	}
+
+	listingsQuery := query.filter(db)
+	if query.Cursor != "" {
+		cursorId, cursorValue, err := query.decodeCursor()
+		if err != nil {
+			return nil, nil, err
+		}
+		listingsQuery = listingsQuery.Where(fmt.Sprintf("(%s, listings.id) %s (?, ?)", column, operator), cursorValue, cursorId)
+	}
+
+	// Fetch an extra listing to know if there's another page
+	listings := []Listing{}
+	listingsQuery.Scopes(PreloadListingSummaries).Order(fmt.Sprintf("%s %s, listings.id %s", column, direction, direction)).Limit(query.Limit + 1).Find(&listings)
+
+	hasNextPage := len(listings) > query.Limit
+	if hasNextPage {
+		listings = listings[:query.Limit]
+	}
+	// The most_bids cursor needs the bid counts, so they're loaded first
+	LoadBidSummaries(db, listings)
+
+	var nextCursor *string
+	if hasNextPage {
+		cursor := query.encodeCursor(listings[len(listings)-1])
+		nextCursor = &cursor
+	}
+	return listings, nextCursor, nil
+}
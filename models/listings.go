@@ -10,6 +10,7 @@ import (
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
+	"github.com/kayprogrammer/bidout-auction-v7/config"
 	"github.com/kayprogrammer/bidout-auction-v7/utils"
 )
 
@@ -18,8 +19,17 @@ type Category struct {
 	BaseModel
 	Name				string				`json:"name" gorm:"not null" example:"Category"`
 	Slug				*string				`json:"slug" gorm:"not null;unique" example:"category_slug"`
+	ParentId			*uuid.UUID			`json:"-" gorm:"null;index"`
+	ParentObj			*Category			`json:"-" gorm:"foreignKey:ParentId;constraint:OnDelete:RESTRICT;null"`
+	Parent				*string				`json:"parent" gorm:"-" example:"parent_category_slug"`
+	DisplayOrder		int					`json:"display_order" gorm:"default:0;not null" example:"0"`
+	Icon				*string				`json:"icon" gorm:"null" example:"fa-solid fa-laptop"`
+	Children			[]Category			`json:"children,omitempty" gorm:"-"`
 }
 
+// Slugs that clash with the category routes
+var reservedCategorySlugs = map[string]bool{"other": true, "tree": true}
+
 // Function to retrieve a category by slug
 func getCategoryBySlug(db *gorm.DB, slug *string) Category {
 	var category Category
@@ -36,12 +46,14 @@ func getCategoryBySlug(db *gorm.DB, slug *string) Category {
 
 func (c *Category) BeforeSave(tx *gorm.DB) (err error) {
 	// Check if the Name field has changed
+	var previousSlug *string
 	if c.ID != uuid.Nil {
 		var oldCategory Category
 		if result := tx.First(&oldCategory, "id = ?", c.ID); result.Error == nil {
 			// Compare the old Name with the new Name
 			if oldCategory.Name != c.Name {
-				// Generate new slug based on the updated Name
+				// Generate new slug based on the updated Name, keeping the old one for existing links
+				previousSlug = oldCategory.Slug
 				createdSlug := slug.Make(c.Name)
 				c.Slug = &createdSlug
 			}
@@ -56,7 +68,7 @@ func (c *Category) BeforeSave(tx *gorm.DB) (err error) {
 
 	for {
 		slugExists := getCategoryBySlug(tx, c.Slug)
-		if slugExists.ID == c.ID || slugExists.ID == uuid.Nil {
+		if (slugExists.ID == c.ID || slugExists.ID == uuid.Nil) && !reservedCategorySlugs[*c.Slug] {
 			// Unique slug found, break the loop
 			break
 		}
@@ -66,6 +78,9 @@ func (c *Category) BeforeSave(tx *gorm.DB) (err error) {
 		newSlug := fmt.Sprintf("%s-%s", *c.Slug, randomStr)
 		c.Slug = &newSlug
 	}	
+	if previousSlug != nil && *previousSlug != *c.Slug {
+		recordSlugHistory(tx, SlugResourceCategory, c.ID, *previousSlug)
+	}
 	return
 }
 
@@ -88,25 +103,88 @@ type Listing struct {
 	Category			string				`json:"category" gorm:"-"`
 
 	Active				bool				`json:"active" gorm:"default:true"`
+	AuctionType			string				`json:"auction_type" gorm:"type:varchar(30);default:english;not null" example:"english"`
+	Currency			string				`json:"currency" gorm:"type:varchar(3);not null" example:"USD"`
 	Price				decimal.Decimal		`json:"price" gorm:"default:0"`
+	Quantity			int					`json:"quantity" gorm:"default:1;not null" example:"1"`
+	PricingRule			string				`json:"pricing_rule" gorm:"type:varchar(20);default:discriminatory;not null" example:"discriminatory"`
 	HighestBid			decimal.Decimal		`json:"highest_bid" gorm:"-"`
 	BidsCount			int					`json:"bids_count" gorm:"-"`
+	StartsAt			*time.Time			`json:"starts_at" gorm:"null"`
 	ClosingDate			time.Time			`json:"closing_date" gorm:"not null"`
+	Status				string				`json:"status" gorm:"-" example:"live"`
+	State				string				`json:"state" gorm:"type:varchar(20);default:published;not null;index" example:"published"`
+	RejectionReason		*string				`json:"rejection_reason,omitempty" gorm:"null"`
+	PublishedAt			*time.Time			`json:"published_at" gorm:"null"`
+	CancellationReason	*string				`json:"cancellation_reason,omitempty" gorm:"null"`
+	CancelledAt			*time.Time			`json:"cancelled_at,omitempty" gorm:"null"`
+	FinalizedAt			*time.Time			`json:"-" gorm:"null"`
+
+	// Listings ending without a winner are relisted automatically up to AutoRelist times
+	AutoRelist			int					`json:"auto_relist" gorm:"default:0;not null" example:"2"`
+	RelistCount			int					`json:"relist_count" gorm:"default:0;not null" example:"0"`
+	RelistedFromId		*uuid.UUID			`json:"-" gorm:"null;index"`
+
+	// Dutch auctions only
+	FloorPrice			*decimal.Decimal	`json:"floor_price,omitempty" gorm:"null"`
+	PriceDecrement		*decimal.Decimal	`json:"price_decrement,omitempty" gorm:"null"`
+	DecrementMinutes	*int				`json:"decrement_minutes,omitempty" gorm:"null"`
+	CurrentPrice		*decimal.Decimal	`json:"current_price,omitempty" gorm:"-"`
+
+	BuyNowPrice			*decimal.Decimal	`json:"buy_now_price,omitempty" gorm:"null"`
+	BuyNowAvailable		bool				`json:"buy_now_available" gorm:"-"`
+	Display				*DisplayPrices		`json:"display,omitempty" gorm:"-"`
+
+	// Values of the category's attributes (e.g {"mileage": 42000, "year": 2019})
+	Attributes			map[string]interface{}	`json:"attributes" gorm:"type:jsonb;serializer:json"`
+
+	// Bidders must have the bid amount available in their wallet, which is held until they're outbid
+	RequiresDeposit		bool				`json:"requires_deposit" gorm:"default:false;not null"`
 
 	ImageId				uuid.UUID			`json:"-" gorm:"not null"`
 	ImageObj			File				`json:"-" gorm:"foreignKey:ImageId;constraint:OnDelete:SET NULL;null;"`
 	Image				string				`json:"image" gorm:"-"`
+	Images				[]ListingImage		`json:"images,omitempty" gorm:"-"`
 
 	Watchlist			bool				`json:"watchlist" gorm:"-"`
 	TimeLeftSecs		int64				`json:"time_left_seconds" gorm:"-"`
 
 	Bids				[]Bid				`json:"-"`
+	// Set by LoadBidSummaries for list views, which don't load the bids themselves
+	bidSummary			*bidSummary
+
+	// Deleted listings are archived: hidden everywhere but kept with their bids for auditing
+	DeletedAt			gorm.DeletedAt		`json:"-" gorm:"index"`
+	Archived			bool				`json:"archived" gorm:"-"`
 }
 
+// Auction statuses
+const (
+	ListingUpcoming				= "upcoming"
+	ListingLive					= "live"
+	ListingEnded				= "ended"
+)
+
+// Pricing rules of multi-quantity lots
+const (
+	PricingUniform				= "uniform"			// every winner pays the lowest winning bid
+	PricingDiscriminatory		= "discriminatory"	// every winner pays their own bid
+)
+
+// Auction types
+const (
+	AuctionEnglish				= "english"
+	AuctionSealedFirstPrice		= "sealed_first_price"
+	AuctionSealedSecondPrice	= "sealed_second_price"
+	AuctionDutch				= "dutch"
+	AuctionReverse				= "reverse"
+)
+
 // Function to retrieve a listing by slug
 func getListingBySlug(db *gorm.DB, slug *string) Listing {
 	var listing Listing
-	result := db.Take(&listing, Listing{Slug: slug})
+	// Archived listings keep their slugs
+	result := db.Unscoped().Take(&listing, Listing{Slug: slug})
 	err := result.Error 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -120,14 +198,20 @@ func getListingBySlug(db *gorm.DB, slug *string) Listing {
 func (listing *Listing) BeforeSave(tx *gorm.DB) (err error) {
     listing.Price = listing.Price.Round(2)
     listing.HighestBid = listing.HighestBid.Round(2)
+	if listing.Currency == "" {
+		// Listings created without a currency are priced in the configured base one
+		listing.Currency = BaseCurrency()
+	}
 
 	// Check if the Name field has changed
+	var previousSlug *string
 	if listing.ID != uuid.Nil {
 		var oldListing Listing
 		if result := tx.First(&oldListing, "id = ?", listing.ID); result.Error == nil {
 			// Compare the old Name with the new Name
 			if oldListing.Name != listing.Name {
-				// Generate new slug based on the updated Name
+				// Generate new slug based on the updated Name, keeping the old one for existing links
+				previousSlug = oldListing.Slug
 				createdSlug := slug.Make(listing.Name)
 				listing.Slug = &createdSlug
 			}
@@ -152,6 +236,9 @@ func (listing *Listing) BeforeSave(tx *gorm.DB) (err error) {
 		newSlug := fmt.Sprintf("%s-%s", *listing.Slug, randomStr)
 		listing.Slug = &newSlug
 	}
+	if previousSlug != nil && *previousSlug != *listing.Slug {
+		recordSlugHistory(tx, SlugResourceListing, listing.ID, *previousSlug)
+	}
 	return
 }
 
@@ -169,14 +256,128 @@ func (listing Listing) TimeLeft() int64 {
 	return listing.TimeLeftSeconds()
 }
 
+// Checks if the listing can no longer receive bids
+func (listing Listing) IsClosed() bool {
+	return !listing.Active || listing.TimeLeftSeconds() < 1
+}
+
+// Checks if the listing is scheduled to start in the future
+func (listing Listing) IsUpcoming() bool {
+	return listing.StartsAt != nil && listing.StartsAt.After(time.Now().UTC())
+}
+
+// Returns the time bidding opens: when the listing was published, or its scheduled start if
+// that's later. Listings that aren't published yet haven't started
+func (listing Listing) StartTime() time.Time {
+	startTime := time.Now().UTC()
+	if listing.PublishedAt != nil {
+		startTime = listing.PublishedAt.UTC()
+	} else if listing.IsPublic() {
+		// Listings published before the publish time was kept
+		startTime = listing.CreatedAt.UTC()
+	}
+	if listing.StartsAt != nil && listing.StartsAt.After(startTime) {
+		startTime = listing.StartsAt.UTC()
+	}
+	return startTime
+}
+
+func (listing Listing) AuctionStatus() string {
+	if listing.IsClosed() {
+		return ListingEnded
+	} else if listing.IsUpcoming() {
+		return ListingUpcoming
+	}
+	return ListingLive
+}
+
+// Scopes a listings query to an auction status
+func FilterByStatus(status string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		now := time.Now().UTC()
+		switch status {
+		case ListingUpcoming:
+			return db.Where("listings.active = ? AND listings.closing_date > ? AND listings.starts_at > ?", true, now, now)
+		case ListingLive:
+			return db.Where("listings.active = ? AND listings.closing_date > ? AND (listings.starts_at IS NULL OR listings.starts_at <= ?)", true, now, now)
+		case ListingEnded:
+			return db.Where("listings.active = ? OR listings.closing_date <= ?", false, now)
+		}
+		return db
+	}
+}
+
+// Checks if the listing sells several identical units
+func (listing Listing) IsMultiUnit() bool {
+	return listing.Quantity > 1
+}
+
+// Checks if bid amounts are hidden until the listing closes
+func (listing Listing) IsSealed() bool {
+	return listing.AuctionType == AuctionSealedFirstPrice || listing.AuctionType == AuctionSealedSecondPrice
+}
+
+// Returns the current asking price of a dutch auction, which drops by the decrement
+// after every interval until it reaches the floor price
+func (listing Listing) DutchCurrentPrice() decimal.Decimal {
+	if listing.PriceDecrement == nil || listing.DecrementMinutes == nil || *listing.DecrementMinutes < 1 {
+		return listing.Price
+	}
+	if listing.IsUpcoming() {
+		return listing.Price
+	}
+	elapsed := time.Now().UTC().Sub(listing.StartTime())
+	steps := int64(elapsed / (time.Duration(*listing.DecrementMinutes) * time.Minute))
+	price := listing.Price.Sub(listing.PriceDecrement.Mul(decimal.NewFromInt(steps)))
+	floorPrice := decimal.NewFromFloat(0.00)
+	if listing.FloorPrice != nil {
+		floorPrice = *listing.FloorPrice
+	}
+	if price.LessThan(floorPrice) {
+		price = floorPrice
+	}
+	return price.Round(2)
+}
+
+// Checks if the listing can still be bought at its buy-now price. The option goes away
+// once the highest bid reaches the configured percentage of the buy-now price, so sealed
+// listings never offer it as that would give their hidden bids away
+func (listing Listing) CanBuyNow(highestBid decimal.Decimal) bool {
+	if listing.BuyNowPrice == nil || listing.IsSealed() || listing.IsClosed() || listing.IsUpcoming() {
+		return false
+	}
+	thresholdPercent := decimal.NewFromInt(int64(config.GetConfig().BuyNowThresholdPercent))
+	threshold := listing.BuyNowPrice.Mul(thresholdPercent).Div(decimal.NewFromInt(100))
+	return highestBid.LessThan(threshold)
+}
+
+// Returns the number of bids, from the summary when the bids themselves weren't loaded
+func (listing Listing) GetBidsCount() int {
+	if listing.bidSummary != nil {
+		return listing.bidSummary.Count
+	}
+	return len(listing.Bids)
+}
+
+// Returns the leading bid amount (the lowest one for reverse auctions)
 func (listing Listing) GetHighestBid() decimal.Decimal {
+	if listing.bidSummary != nil {
+		if listing.AuctionType == AuctionReverse {
+			return listing.bidSummary.Lowest
+		}
+		return listing.bidSummary.Highest
+	}
 	bids := listing.Bids
 	bidsLength := len(bids)
 	highestAmount := decimal.NewFromFloat(0.00)
 	if bidsLength > 0 {
 		highestAmount = bids[0].Amount
 		for _, bid := range bids {
-			if bid.Amount.GreaterThan(highestAmount) {
+			if listing.AuctionType == AuctionReverse {
+				if bid.Amount.LessThan(highestAmount) {
+					highestAmount = bid.Amount
+				}
+			} else if bid.Amount.GreaterThan(highestAmount) {
 				highestAmount = bid.Amount
 			}
 		}
@@ -195,15 +396,21 @@ func (listing Listing) Init(db *gorm.DB) Listing {
 		listing.Auctioneer.Avatar = &url
 	}
 
-	// Get Listing Image
+	// Get Listing Image. Until the cover is uploaded, the next ready image stands in for it
 	imageId := listing.ImageId
 	image := File{}
 	db.Take(&image, imageId)
-	url := utils.GenerateFileUrl(imageId.String(), "listings", image.ResourceType)
-	listing.Image = url
+	if image.Status != FilePending {
+		listing.Image = utils.GenerateFileUrl(imageId.String(), "listings", image.ResourceType)
+	} else if images := listing.GetImages(db.Limit(1)); len(images) > 0 {
+		listing.Image = images[0].Url
+	}
 
 	listing.Price = listing.Price.Round(2)
 	listing.HighestBid = listing.HighestBid.Round(2)
+	if listing.Attributes == nil {
+		listing.Attributes = map[string]interface{}{}
+	}
 	if listing.CategoryId != nil {
 		listing.Category = listing.CategoryObj.Name
 	} else {
@@ -216,17 +423,180 @@ func (listing Listing) Init(db *gorm.DB) Listing {
 	}
 	listing.ClosingDate = listing.ClosingDate.UTC()
 	listing.TimeLeftSecs = listing.TimeLeftSeconds()
+	listing.Status = listing.AuctionStatus()
+	listing.Archived = listing.DeletedAt.Valid
+	if listing.StartsAt != nil {
+		startsAt := listing.StartsAt.UTC()
+		listing.StartsAt = &startsAt
+	}
 
-	listing.BidsCount = len(listing.Bids)
+	listing.BidsCount = listing.GetBidsCount()
 	listing.HighestBid = listing.GetHighestBid()
+	if listing.IsSealed() && listing.Active {
+		// Keep sealed amounts hidden until the auction closes
+		listing.HighestBid = decimal.NewFromFloat(0.00)
+	}
+	listing.BuyNowAvailable = listing.CanBuyNow(listing.HighestBid)
+	if listing.AuctionType == AuctionDutch {
+		currentPrice := listing.DutchCurrentPrice()
+		listing.CurrentPrice = &currentPrice
+	}
+	return listing
+}
+
+// Adds the listing's prices converted to another currency. Unsupported currencies are ignored
+func (listing Listing) InCurrency(db *gorm.DB, currency string) Listing {
+	if currency == "" || currency == listing.Currency || !CurrencySupported(db, currency) {
+		return listing
+	}
+	convert := func(amount decimal.Decimal) decimal.Decimal {
+		converted, _ := ConvertAmount(db, amount, listing.Currency, currency)
+		return converted
+	}
+	display := DisplayPrices{Currency: currency, Price: convert(listing.Price), HighestBid: convert(listing.HighestBid)}
+	if listing.CurrentPrice != nil {
+		currentPrice := convert(*listing.CurrentPrice)
+		display.CurrentPrice = &currentPrice
+	}
+	if listing.BuyNowPrice != nil {
+		buyNowPrice := convert(*listing.BuyNowPrice)
+		display.BuyNowPrice = &buyNowPrice
+	}
+	listing.Display = &display
 	return listing
 }
 
 func (listing Listing) GetImageUploadData(db *gorm.DB) utils.SignatureFormat {
 	imageId := listing.ImageId
-	uploadData := utils.GenerateFileSignature(imageId.String(), "listings")
+	image := File{}
+	db.Take(&image, imageId)
+	uploadData := utils.GenerateFileSignature(imageId.String(), "listings", image.ResourceType)
 	return uploadData
 }
+
+// The bid totals list views show, counted for a page of listings at once
+type bidSummary struct {
+	ListingId			uuid.UUID
+	Count				int
+	Highest				decimal.Decimal
+	Lowest				decimal.Decimal
+}
+
+// Preloads what list views show of listings. Their bids are left out since there can be many,
+// so LoadBidSummaries should be called on the results before they're initialized
+func PreloadListingSummaries(db *gorm.DB) *gorm.DB {
+	return db.Preload("AuctioneerObj").Preload("CategoryObj").Preload("ImageObj")
+}
+
+// Counts the bids of the listings and finds their leading amounts in one query
+func LoadBidSummaries(db *gorm.DB, listings []Listing) {
+	if len(listings) == 0 {
+		return
+	}
+	listingIds := []uuid.UUID{}
+	for _, listing := range listings {
+		listingIds = append(listingIds, listing.ID)
+	}
+	summaries := []bidSummary{}
+	db.Model(&Bid{}).Select("listing_id, COUNT(*) AS count, MAX(amount) AS highest, MIN(amount) AS lowest").
+		Where("listing_id IN ?", listingIds).Group("listing_id").Scan(&summaries)
+	summariesById := map[uuid.UUID]bidSummary{}
+	for _, summary := range summaries {
+		summariesById[summary.ListingId] = summary
+	}
+	for i := range listings {
+		summary := summariesById[listings[i].ID]
+		listings[i].bidSummary = &summary
+	}
+}
+
+// Adds the listing's gallery. It's left out of Init so list views don't query it per listing
+func (listing Listing) WithImages(db *gorm.DB) Listing {
+	listing.Images = listing.GetImages(db)
+	return listing
+}
+
+// Returns the listing's gallery in display order, leaving out images that aren't uploaded yet
+func (listing Listing) GetImages(db *gorm.DB) []ListingImage {
+	return listing.getImages(db.Joins("File").Where(`"File".status = ?`, FileReady))
+}
+
+// Returns the listing's whole gallery in display order, for its owner to manage
+func (listing Listing) GetAllImages(db *gorm.DB) []ListingImage {
+	return listing.getImages(db.Joins("File"))
+}
+
+func (listing Listing) getImages(query *gorm.DB) []ListingImage {
+	images := []ListingImage{}
+	query.Where("listing_images.listing_id = ?", listing.ID).Order("listing_images.position ASC, listing_images.created_at ASC").Find(&images)
+	for i := range images {
+		images[i] = images[i].Init(listing.ImageId)
+	}
+	return images
+}
+
+// Adds the cover image to the gallery of a new listing
+func (listing *Listing) AfterCreate(tx *gorm.DB) (err error) {
+	if listing.ImageId == uuid.Nil {
+		return
+	}
+	return tx.Create(&ListingImage{ListingId: listing.ID, FileId: listing.ImageId}).Error
+}
+// ---------------------------------------------------------------
+
+// LISTING IMAGE
+type ListingImage struct {
+	BaseModel
+	Identifier			uuid.UUID			`json:"id" gorm:"-"`
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index"`
+	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
+	FileId				uuid.UUID			`json:"-" gorm:"not null"`
+	File				File				`json:"-" gorm:"foreignKey:FileId;constraint:OnDelete:CASCADE;not null;"`
+	Position			int					`json:"position" gorm:"default:0;not null" example:"0"`
+	Url					string				`json:"url" gorm:"-"`
+	IsCover				bool				`json:"is_cover" gorm:"-"`
+	Status				string				`json:"status" gorm:"-" example:"ready"`
+}
+
+// The number of images a listing's gallery can hold
+const MaxListingImages = 10
+
+// Adds gallery rows for listings created before galleries existed
+func SetupListingImages(db *gorm.DB) {
+	db.Exec(`INSERT INTO listing_images (listing_id, file_id, position, created_at, updated_at)
+		SELECT listings.id, listings.image_id, 0, now(), now() FROM listings
+		WHERE NOT EXISTS (SELECT 1 FROM listing_images WHERE listing_images.listing_id = listings.id)`)
+}
+
+func (image ListingImage) Init(coverId uuid.UUID) ListingImage {
+	image.Identifier = image.ID
+	image.Url = utils.GenerateFileUrl(image.FileId.String(), "listings", image.File.ResourceType)
+	image.IsCover = image.FileId == coverId
+	image.Status = image.File.Status
+	return image
+}
+
+func (image ListingImage) GetUploadData() utils.SignatureFormat {
+	return utils.GenerateFileSignature(image.FileId.String(), "listings", image.File.ResourceType)
+}
+
+// Numbers the listing's images by their order in the slice and makes the first one the cover
+func ReorderListingImages(db *gorm.DB, listing *Listing, images []ListingImage) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, image := range images {
+			if err := tx.Model(&ListingImage{}).Where("id = ?", image.ID).Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+		if len(images) > 0 && listing.ImageId != images[0].FileId {
+			if err := tx.Model(listing).UpdateColumn("image_id", images[0].FileId).Error; err != nil {
+				return err
+			}
+			listing.ImageId = images[0].FileId
+		}
+		return nil
+	})
+}
 // ---------------------------------------------------------------
 
 // BID
@@ -236,16 +606,40 @@ type Bid struct {
 	UserObj				User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;not null;"`
 	User				ShortUserData		`json:"user" gorm:"-"`
 
-	ListingId			uuid.UUID			`json:"-" gorm:"column:listing_id;not null;index:,unique,composite:user_id_listing_id;index:,unique,composite:listing_id_amount"`
+	ListingId			uuid.UUID			`json:"-" gorm:"column:listing_id;not null;index:,unique,composite:user_id_listing_id;index:,composite:listing_amount"`
 	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
-	Amount				decimal.Decimal		`json:"amount" gorm:"not null;index:,unique,composite:listing_id_amount"`
+	Amount				decimal.Decimal		`json:"amount" gorm:"not null;index:,composite:listing_amount"`
+	Quantity			int					`json:"quantity" gorm:"default:1;not null"`
+	Sealed				bool				`json:"sealed" gorm:"-"`
+	DisplayAmount		*decimal.Decimal	`json:"display_amount,omitempty" gorm:"-"`
+	// Set on bids of single-unit english auctions, the only ones whose amounts can't tie
+	OpenAscending		bool				`json:"-" gorm:"default:false;not null"`
 }
 
 func (bid *Bid) BeforeSave(tx *gorm.DB) (err error) {
     bid.Amount = bid.Amount.Round(2)
+	listing := Listing{}
+	tx.Unscoped().Select("auction_type", "quantity").Take(&listing, bid.ListingId)
+	bid.OpenAscending = listing.AuctionType == AuctionEnglish && !listing.IsMultiUnit()
     return
 }
 
+// Replaces the old unique index on bid amounts, which stopped sealed bids and lots from tying,
+// with one covering english auctions only
+func SetupBidIndexes(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&Bid{}, "idx_bids_listing_id_amount") {
+		if err := db.Migrator().DropIndex(&Bid{}, "idx_bids_listing_id_amount"); err != nil {
+			return err
+		}
+	}
+	err := db.Exec(`UPDATE bids SET open_ascending = true FROM listings WHERE listings.id = bids.listing_id
+		AND listings.auction_type = ? AND listings.quantity = 1 AND NOT bids.open_ascending`, AuctionEnglish).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_bids_listing_id_amount_open ON bids (listing_id, amount) WHERE open_ascending").Error
+}
+
 func (bid Bid) Init(db *gorm.DB) Bid {
 	user := User{}
 	db.Take(&user, bid.UserId)
@@ -264,6 +658,56 @@ func (bid Bid) Init(db *gorm.DB) Bid {
 	return bid
 }
 
+// Adds the bid amount converted from the listing's currency to another one
+func (bid Bid) InCurrency(db *gorm.DB, listingCurrency string, currency string) Bid {
+	if currency == "" || currency == listingCurrency {
+		return bid
+	}
+	if amount, ok := ConvertAmount(db, bid.Amount, listingCurrency, currency); ok {
+		bid.DisplayAmount = &amount
+	}
+	return bid
+}
+
+// Hides the amount of a bid placed on a sealed listing that is still open
+func (bid Bid) Seal() Bid {
+	bid.Amount = decimal.NewFromFloat(0.00)
+	bid.DisplayAmount = nil
+	bid.Sealed = true
+	return bid
+}
+
+// -------------------------------------------------------------------------
+
+// AUCTION RESULT
+type AuctionResult struct {
+	BaseModel
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index"`
+	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
+
+	WinnerId			uuid.UUID			`json:"-" gorm:"not null"`
+	WinnerObj			User				`json:"-" gorm:"foreignKey:WinnerId;constraint:OnDelete:CASCADE;not null;"`
+	Winner				ShortUserData		`json:"winner" gorm:"-"`
+
+	BidId				*uuid.UUID			`json:"-" gorm:"null"`
+	Bid					*Bid				`json:"-" gorm:"foreignKey:BidId;constraint:OnDelete:CASCADE;null;"`
+	Quantity			int					`json:"quantity" gorm:"default:1;not null"`
+	UnitPrice			decimal.Decimal		`json:"unit_price" gorm:"not null"`
+	Amount				decimal.Decimal		`json:"amount" gorm:"not null"`
+	BuyNow				bool				`json:"buy_now" gorm:"default:false"`
+}
+
+func (result AuctionResult) Init(db *gorm.DB) AuctionResult {
+	user := User{}
+	db.Take(&user, result.WinnerId)
+	result.Winner.ID = user.ID
+	result.Winner.Name = user.FullName()
+	result.Winner.Avatar = user.GetAvatarUrl(db)
+	result.UnitPrice = result.UnitPrice.Round(2)
+	result.Amount = result.Amount.Round(2)
+	return result
+}
+
 // -------------------------------------------------------------------------
 
 // WATCHLIST
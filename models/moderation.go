@@ -0,0 +1,64 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Listing states
+const (
+	ListingStateDraft			= "draft"
+	ListingStatePendingReview	= "pending_review"
+	ListingStatePublished		= "published"
+	ListingStateRejected		= "rejected"
+	ListingStateCancelled		= "cancelled"
+	ListingStateEnded			= "ended"
+)
+
+// The states a listing can move to from each state
+var listingTransitions = map[string][]string{
+	ListingStateDraft:			{ListingStatePendingReview, ListingStateCancelled},
+	ListingStatePendingReview:	{ListingStatePublished, ListingStateRejected, ListingStateCancelled},
+	ListingStateRejected:		{ListingStatePendingReview, ListingStateCancelled},
+	ListingStatePublished:		{ListingStateEnded, ListingStateCancelled},
+}
+
+// Checks if the listing can move from its current state to another one
+func (listing Listing) CanTransitionTo(state string) bool {
+	for _, nextState := range listingTransitions[listing.State] {
+		if nextState == state {
+			return true
+		}
+	}
+	return false
+}
+
+// The states of listings that have passed moderation. Ended listings stay visible so
+// bidders can still see the results
+func PublicListingStates() []string {
+	return []string{ListingStatePublished, ListingStateEnded}
+}
+
+// Checks if anyone can see the listing
+func (listing Listing) IsPublic() bool {
+	for _, state := range PublicListingStates() {
+		if listing.State == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes a listings query to the listings anyone can see
+func PubliclyVisible(db *gorm.DB) *gorm.DB {
+	return db.Where("listings.state IN ?", PublicListingStates())
+}
+
+// Moves a listing out of review. The update only applies while the listing is still pending
+// review, so it returns false when another moderator got to it first
+func (listing *Listing) Moderate(db *gorm.DB, updates map[string]interface{}) (bool, error) {
+	result := db.Model(listing).Where("state = ?", ListingStatePendingReview).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Notification kinds (users can opt out of each one)
+const (
+	NotificationOutbid			= "outbid"
+	NotificationEndingSoon		= "ending_soon"
+	NotificationAuctionLive		= "auction_live"
+	NotificationSavedSearch		= "saved_search"
+	NotificationQuestionAsked	= "question_asked"
+	NotificationQuestionAnswered	= "question_answered"
+)
+
+// Notification kinds users can't opt out of
+const (
+	NotificationModeration		= "moderation"
+	NotificationCancelled		= "listing_cancelled"
+	NotificationListingUpdated	= "listing_updated"
+)
+
+func NotificationKinds() []string {
+	return []string{NotificationOutbid, NotificationEndingSoon, NotificationAuctionLive, NotificationSavedSearch, NotificationQuestionAsked, NotificationQuestionAnswered}
+}
+
+// NOTIFICATION (a record of every notification sent, used for de-duplication)
+type Notification struct {
+	BaseModel
+	UserId				uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:user_id_kind_key"`
+	User				User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;not null;"`
+	Kind				string				`json:"kind" gorm:"type:varchar(30);not null;index:,unique,composite:user_id_kind_key"`
+	Key					string				`json:"-" gorm:"not null;index:,unique,composite:user_id_kind_key"`
+}
+
+// NOTIFICATION PREFERENCE (only opt-outs need to be stored, every kind is enabled by default)
+type NotificationPreference struct {
+	BaseModel
+	UserId				uuid.UUID			`json:"-" gorm:"not null;index:,unique,composite:user_id_kind"`
+	User				User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;not null;"`
+	Kind				string				`json:"kind" gorm:"type:varchar(30);not null;index:,unique,composite:user_id_kind"`
+	Enabled				bool				`json:"enabled" gorm:"not null"`
+}
+
+// Checks if a user still wants to receive a kind of notification
+func NotificationEnabled(db *gorm.DB, userId uuid.UUID, kind string) bool {
+	preference := NotificationPreference{}
+	result := db.Take(&preference, NotificationPreference{UserId: userId, Kind: kind})
+	if result.Error != nil {
+		return true
+	}
+	return preference.Enabled
+}
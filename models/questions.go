@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// LISTING QUESTION (a public question about a listing, which only its auctioneer can answer)
+type ListingQuestion struct {
+	BaseModel
+	Identifier			uuid.UUID			`json:"id" gorm:"-"`
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index"`
+	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null;"`
+	UserId				uuid.UUID			`json:"-" gorm:"not null"`
+	UserObj				User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;not null;"`
+	User				ShortUserData		`json:"user" gorm:"-"`
+
+	Question			string				`json:"question" gorm:"type:varchar(500);not null" example:"Does it come with a charger?"`
+	Answer				*string				`json:"answer" gorm:"type:varchar(1000);null" example:"Yes, the original one."`
+	AskedAt				time.Time			`json:"asked_at" gorm:"-"`
+	AnsweredAt			*time.Time			`json:"answered_at" gorm:"null"`
+
+	// Set by staff to take a question off the listing
+	Hidden				bool				`json:"hidden" gorm:"not null;default:false"`
+	HiddenReason		*string				`json:"hidden_reason,omitempty" gorm:"null"`
+}
+
+func (question ListingQuestion) Init(db *gorm.DB) ListingQuestion {
+	question.Identifier = question.ID
+	question.AskedAt = question.CreatedAt
+	question.User = ShortUserData{ID: question.UserObj.ID, Name: question.UserObj.FullName(), Avatar: question.UserObj.GetAvatarUrl(db)}
+	return question
+}
+
+// Returns a page of the questions matching the conditions, latest first, with the number of matches
+func FindListingQuestions(db *gorm.DB, conditions map[string]interface{}, page int, limit int) ([]ListingQuestion, int64) {
+	questionsQuery := db.Model(&ListingQuestion{}).Where(conditions)
+	var total int64
+	questionsQuery.Session(&gorm.Session{}).Count(&total)
+
+	questions := []ListingQuestion{}
+	questionsQuery.Preload("UserObj").Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&questions)
+	for i := range questions {
+		questions[i] = questions[i].Init(db)
+	}
+	return questions, total
+}
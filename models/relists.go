@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAlreadyRelisted = errors.New("listing was relisted already")
+
+// Checks if the listing can be relisted. Only auctions that are over can be
+func (listing Listing) CanRelist() bool {
+	return listing.State == ListingStateEnded
+}
+
+// Checks if a copy of the listing was relisted already. Deleting the copy allows relisting again
+func (listing Listing) WasRelisted(db *gorm.DB) bool {
+	var count int64
+	db.Model(&Listing{}).Where("relisted_from_id = ?", listing.ID).Count(&count)
+	return count > 0
+}
+
+// Checks if the listing should relist itself now that it ended without a winner
+func (listing Listing) ShouldAutoRelist() bool {
+	return listing.RelistCount < listing.AutoRelist
+}
+
+// Returns how long bidding was open on the listing
+func (listing Listing) AuctionDuration() time.Duration {
+	openedAt := listing.CreatedAt
+	if listing.StartsAt != nil {
+		openedAt = *listing.StartsAt
+	} else if listing.PublishedAt != nil {
+		openedAt = *listing.PublishedAt
+	}
+	return listing.ClosingDate.Sub(openedAt)
+}
+
+// Copies the listing's details, auction settings and images into a new listing
+func (listing Listing) relistCopy(state string, closingDate time.Time) Listing {
+	return Listing{
+		AuctioneerId:		listing.AuctioneerId,
+		Name:				listing.Name,
+		Desc:				listing.Desc,
+		CategoryId:			listing.CategoryId,
+		Active:				true,
+		AuctionType:		listing.AuctionType,
+		Currency:			listing.Currency,
+		Price:				listing.Price,
+		Quantity:			listing.Quantity,
+		PricingRule:		listing.PricingRule,
+		ClosingDate:		closingDate,
+		State:				state,
+		FloorPrice:			listing.FloorPrice,
+		PriceDecrement:		listing.PriceDecrement,
+		DecrementMinutes:	listing.DecrementMinutes,
+		BuyNowPrice:		listing.BuyNowPrice,
+		Attributes:			listing.Attributes,
+		RequiresDeposit:	listing.RequiresDeposit,
+		ImageId:			listing.ImageId,
+		AutoRelist:			listing.AutoRelist,
+		RelistedFromId:		&listing.ID,
+	}
+}
+
+// Saves a relisted copy of the listing along with its gallery, which shares the listing's files.
+// A listing is only ever relisted once, so it's locked while it's checked and copied
+func (listing Listing) saveRelist(db *gorm.DB, relist *Listing) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&Listing{}, listing.ID).Error; err != nil {
+			return err
+		}
+		if listing.WasRelisted(tx) {
+			return ErrAlreadyRelisted
+		}
+		images := []ListingImage{}
+		err := tx.Joins("File").Where(`listing_images.listing_id = ? AND "File".status = ?`, listing.ID, FileReady).
+			Order("listing_images.position ASC, listing_images.created_at ASC").Find(&images).Error
+		if err != nil {
+			return err
+		}
+
+		// The cover is added to the gallery when the listing is created
+		if err := tx.Create(relist).Error; err != nil {
+			return err
+		}
+		for _, image := range images {
+			if image.FileId == relist.ImageId {
+				continue
+			}
+			if err := tx.Create(&ListingImage{ListingId: relist.ID, FileId: image.FileId, Position: image.Position}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Copies the listing into a new draft that closes at the closing date. The draft starts at
+// the price when one is given
+func (listing Listing) Relist(db *gorm.DB, closingDate time.Time, startsAt *time.Time, price *decimal.Decimal) (*Listing, error) {
+	relist := listing.relistCopy(ListingStateDraft, closingDate)
+	relist.StartsAt = startsAt
+	if price != nil {
+		relist.Price = *price
+	}
+	if err := listing.saveRelist(db, &relist); err != nil {
+		return nil, err
+	}
+	return &relist, nil
+}
+
+// Publishes a copy of a listing that ended without a winner, open for as long as the listing was.
+// Its details were approved already so it skips review
+func (listing Listing) AutoRelistListing(db *gorm.DB) (*Listing, error) {
+	relist := listing.relistCopy(ListingStatePublished, time.Now().UTC().Add(listing.AuctionDuration()))
+	publishedAt := time.Now().UTC()
+	relist.PublishedAt = &publishedAt
+	relist.RelistCount = listing.RelistCount + 1
+	if err := listing.saveRelist(db, &relist); err != nil {
+		return nil, err
+	}
+	return &relist, nil
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// LISTING REVISION (an edit made to the name or description of a live listing)
+type ListingRevision struct {
+	BaseModel
+	ListingId			uuid.UUID			`json:"-" gorm:"not null;index"`
+	Listing				Listing				`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:CASCADE;not null"`
+	Field				string				`json:"field" gorm:"type:varchar(20);not null" example:"name"`
+	OldValue			string				`json:"old_value" gorm:"not null" example:"Old product name"`
+	NewValue			string				`json:"new_value" gorm:"not null" example:"New product name"`
+	EditedAt			time.Time			`json:"edited_at" gorm:"-"`
+}
+
+func (revision ListingRevision) Init() ListingRevision {
+	revision.EditedAt = revision.CreatedAt
+	return revision
+}
+
+// Returns the fields changed between two versions of a listing that bidders rely on, so
+// they can't be changed once a listing has bids
+func BidLockedChanges(before Listing, after Listing) []string {
+	changes := []string{}
+	if !before.Price.Equal(after.Price) {
+		changes = append(changes, "price")
+	}
+	if !before.ClosingDate.Equal(after.ClosingDate) {
+		changes = append(changes, "closing_date")
+	}
+	if (before.BuyNowPrice == nil) != (after.BuyNowPrice == nil) || (before.BuyNowPrice != nil && !before.BuyNowPrice.Equal(*after.BuyNowPrice)) {
+		changes = append(changes, "buy_now_price")
+	}
+	if (before.CategoryId == nil) != (after.CategoryId == nil) || (before.CategoryId != nil && *before.CategoryId != *after.CategoryId) {
+		changes = append(changes, "category")
+	}
+	return changes
+}
+
+// Returns a readable list of what was changed on the details of a listing
+func ListingDetailChanges(before Listing, after Listing) []string {
+	changes := []string{}
+	if before.Name != after.Name {
+		changes = append(changes, "name")
+	}
+	if before.Desc != after.Desc {
+		changes = append(changes, "description")
+	}
+	beforeAttributes, _ := json.Marshal(before.Attributes)
+	afterAttributes, _ := json.Marshal(after.Attributes)
+	if (len(before.Attributes) > 0 || len(after.Attributes) > 0) && string(beforeAttributes) != string(afterAttributes) {
+		changes = append(changes, "attributes")
+	}
+	return changes
+}
+
+// Checks if anyone has bid on the listing
+func (listing Listing) HasBids(db *gorm.DB) bool {
+	var bidsCount int64
+	db.Model(&Bid{}).Where("listing_id = ?", listing.ID).Count(&bidsCount)
+	return bidsCount > 0
+}
+
+// Records the name and description edits between two versions of a listing
+func RecordListingRevisions(db *gorm.DB, before Listing, after Listing) []ListingRevision {
+	revisions := []ListingRevision{}
+	if before.Name != after.Name {
+		revisions = append(revisions, ListingRevision{ListingId: after.ID, Field: "name", OldValue: before.Name, NewValue: after.Name})
+	}
+	if before.Desc != after.Desc {
+		revisions = append(revisions, ListingRevision{ListingId: after.ID, Field: "desc", OldValue: before.Desc, NewValue: after.Desc})
+	}
+	if len(revisions) > 0 {
+		db.Create(&revisions)
+	}
+	return revisions
+}
+
+// Returns the revisions of a listing, latest first
+func ListingRevisions(db *gorm.DB, listingId uuid.UUID) []ListingRevision {
+	revisions := []ListingRevision{}
+	db.Where("listing_id = ?", listingId).Order("created_at DESC").Find(&revisions)
+	for i := range revisions {
+		revisions[i] = revisions[i].Init()
+	}
+	return revisions
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// Saved search alert frequencies
+const (
+	AlertInstant				= "instant"
+	AlertDigest					= "digest"
+)
+
+// SAVED SEARCH
+type SavedSearch struct {
+	BaseModel
+	Identifier			uuid.UUID			`json:"id" gorm:"-"`
+	UserId				uuid.UUID			`json:"-" gorm:"not null;index"`
+	User				User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;not null;"`
+	Name				string				`json:"name" gorm:"type:varchar(100);not null" example:"Cheap phones"`
+	Query				string				`json:"query" gorm:"not null" example:"iphone"`
+	TsQuery				string				`json:"-" gorm:"not null"`
+
+	CategoryId			*uuid.UUID			`json:"-" gorm:"null"`
+	CategoryObj			*Category			`json:"-" gorm:"foreignKey:CategoryId;constraint:OnDelete:CASCADE;null"`
+	Category			*string				`json:"category" gorm:"-" example:"category_slug"`
+	MinPrice			*decimal.Decimal	`json:"min_price" gorm:"null" example:"100.00"`
+	MaxPrice			*decimal.Decimal	`json:"max_price" gorm:"null" example:"1000.00"`
+
+	Frequency			string				`json:"frequency" gorm:"type:varchar(10);default:instant;not null" example:"instant"`
+	UnsubscribeToken	string				`json:"-" gorm:"not null;unique"`
+	LastAlertedAt		*time.Time			`json:"last_alerted_at" gorm:"null"`
+}
+
+func (search *SavedSearch) BeforeSave(tx *gorm.DB) (err error) {
+	search.TsQuery = PrefixTsQuery(search.Query)
+	if search.UnsubscribeToken == "" {
+		search.UnsubscribeToken = utils.GetRandomString(40)
+	}
+	return
+}
+
+func (search SavedSearch) Init() SavedSearch {
+	search.Identifier = search.ID
+	if search.CategoryObj != nil {
+		search.Category = search.CategoryObj.Slug
+	}
+	return search
+}
+
+// Checks if the saved search has words the full-text search can match
+func (search SavedSearch) IsSearchable() bool {
+	return search.TsQuery != ""
+}
+
+// The conditions a listing has to meet to match a saved search, with the saved search's
+// columns available as 'saved_searches'. Like listing filters, a category matches its subcategories too
+const savedSearchMatchSQL = `listings.search_vector @@ to_tsquery('english', saved_searches.ts_query)
+	AND saved_searches.ts_query != ''
+	AND listings.auctioneer_id != saved_searches.user_id
+	AND listings.state = 'published' AND listings.active = true AND listings.closing_date > now()
+	AND (saved_searches.category_id IS NULL OR listings.category_id IN (
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = saved_searches.category_id
+			UNION
+			SELECT categories.id FROM categories JOIN tree ON categories.parent_id = tree.id
+		) SELECT id FROM tree
+	))
+	AND (saved_searches.min_price IS NULL OR listings.price >= saved_searches.min_price)
+	AND (saved_searches.max_price IS NULL OR listings.price <= saved_searches.max_price)`
+
+// Returns the saved searches with the given frequency that a listing matches
+func MatchingSavedSearches(db *gorm.DB, listingId uuid.UUID, frequency string) []SavedSearch {
+	searches := []SavedSearch{}
+	db.Preload("User").Joins("JOIN listings ON listings.id = ?", listingId).Where(
+		"saved_searches.frequency = ? AND "+savedSearchMatchSQL, frequency,
+	).Find(&searches)
+	return searches
+}
+
+// Returns the listings published after the given time that match the saved search
+func (search SavedSearch) NewListings(db *gorm.DB, since time.Time) []Listing {
+	listings := []Listing{}
+	db.Joins("JOIN saved_searches ON saved_searches.id = ?", search.ID).Where(
+		"listings.published_at > ? AND "+savedSearchMatchSQL, since,
+	).Order("listings.published_at ASC").Find(&listings)
+	return listings
+}
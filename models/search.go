@@ -0,0 +1,90 @@
+package models
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Weights the listing name over its category name, and both over the description
+const listingSearchVectorSQL = `UPDATE listings SET search_vector =
+	setweight(to_tsvector('english', coalesce(listings.name, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce((SELECT categories.name FROM categories WHERE categories.id = listings.category_id), '')), 'B') ||
+	setweight(to_tsvector('english', coalesce(listings.desc, '')), 'C')`
+
+const highlightOptions = "StartSel=<mark>, StopSel=</mark>"
+
+// Adds the full-text search column and indexes that AutoMigrate can't express, then
+// fills the column for listings that don't have it yet. It stops at the first statement that fails
+func SetupListingSearch(db *gorm.DB) error {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"ALTER TABLE listings ADD COLUMN IF NOT EXISTS search_vector tsvector",
+		"CREATE INDEX IF NOT EXISTS idx_listings_search_vector ON listings USING GIN (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_listings_name_trgm ON listings USING GIN (name gin_trgm_ops)",
+		listingSearchVectorSQL + " WHERE listings.search_vector IS NULL",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			log.Println("Failed to set up listing search:", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Keeps the search vector of a listing in sync with its name, description and category
+func (listing *Listing) AfterSave(tx *gorm.DB) (err error) {
+	return tx.Exec(listingSearchVectorSQL+" WHERE listings.id = ?", listing.ID).Error
+}
+
+// Re-indexes the category's listings since its name is part of their search vector
+func (c *Category) AfterSave(tx *gorm.DB) (err error) {
+	return tx.Exec(listingSearchVectorSQL+" WHERE listings.category_id = ?", c.ID).Error
+}
+
+var searchWordRegex = regexp.MustCompile(`[\pL\pN]+`)
+
+// Turns free text into a tsquery where every word also matches as a prefix (e.g "iph" finds "iphone")
+func PrefixTsQuery(terms string) string {
+	words := searchWordRegex.FindAllString(strings.ToLower(terms), -1)
+	for i := range words {
+		words[i] = words[i] + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// A listing that matched a search
+type ListingSearchHit struct {
+	ID					uuid.UUID
+	Rank				float64
+	NameHighlight		string
+	DescHighlight		string
+}
+
+// Searches listings by name, description and category name. Full-text matches are ranked
+// first while trigram similarity on the name catches typos (e.g "iphnoe")
+func SearchListings(db *gorm.DB, terms string, status string, limit int, offset int) ([]ListingSearchHit, int64) {
+	hits := []ListingSearchHit{}
+	var total int64
+	tsQuery := PrefixTsQuery(terms)
+	if tsQuery == "" {
+		return hits, total
+	}
+
+	query := db.Model(&Listing{}).Scopes(PubliclyVisible, FilterByStatus(status)).Where(
+		"(listings.search_vector @@ to_tsquery('english', ?) OR ? <% listings.name)", tsQuery, terms,
+	).Session(&gorm.Session{})
+	query.Count(&total)
+	query.Select(
+		`listings.id,
+		ts_rank(listings.search_vector, to_tsquery('english', ?)) + word_similarity(?, listings.name) AS rank,
+		ts_headline('english', listings.name, to_tsquery('english', ?), ?) AS name_highlight,
+		ts_headline('english', listings.desc, to_tsquery('english', ?), ?) AS desc_highlight`,
+		tsQuery, terms, tsQuery, highlightOptions+", HighlightAll=true", tsQuery, highlightOptions+", MaxFragments=2, MaxWords=20, MinWords=5",
+	).Order("rank DESC, listings.id").Limit(limit).Offset(offset).Scan(&hits)
+	return hits, total
+}
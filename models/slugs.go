@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Resources that keep their old slugs
+const (
+	SlugResourceListing			= "listing"
+	SlugResourceCategory		= "category"
+)
+
+// SLUG HISTORY (slugs that listings and categories had before being renamed, so old links still work)
+type SlugHistory struct {
+	BaseModel
+	ResourceType		string				`json:"-" gorm:"type:varchar(20);not null;index:,unique,composite:resource_type_slug"`
+	ResourceId			uuid.UUID			`json:"-" gorm:"not null;index"`
+	Slug				string				`json:"-" gorm:"not null;index:,unique,composite:resource_type_slug"`
+}
+
+// Stores the slug a resource had before it was renamed. If another resource used
+// that slug before, the latest one takes it over
+func recordSlugHistory(tx *gorm.DB, resourceType string, resourceId uuid.UUID, slug string) {
+	history := SlugHistory{ResourceType: resourceType, ResourceId: resourceId, Slug: slug}
+	tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "resource_type"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"resource_id", "updated_at"}),
+	}).Create(&history)
+}
+
+// Returns the ID of the resource that used to have a slug
+func slugHistoryOwner(db *gorm.DB, resourceType string, slug string) uuid.UUID {
+	history := SlugHistory{}
+	db.Take(&history, SlugHistory{ResourceType: resourceType, Slug: slug})
+	return history.ResourceId
+}
+
+// Returns the current slug of a public listing that used to have the given slug
+func CanonicalListingSlug(db *gorm.DB, slug string) *string {
+	listingId := slugHistoryOwner(db, SlugResourceListing, slug)
+	if listingId == uuid.Nil {
+		return nil
+	}
+	listing := Listing{}
+	db.Scopes(PubliclyVisible).Take(&listing, listingId)
+	return listing.Slug
+}
+
+// Returns the current slug of a category that used to have the given slug
+func CanonicalCategorySlug(db *gorm.DB, slug string) *string {
+	categoryId := slugHistoryOwner(db, SlugResourceCategory, slug)
+	if categoryId == uuid.Nil {
+		return nil
+	}
+	category := Category{}
+	db.Take(&category, categoryId)
+	return category.Slug
+}
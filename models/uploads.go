@@ -0,0 +1,174 @@
+package models
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// File statuses. Files clients upload stay pending until they confirm the upload
+const (
+	FilePending				= "pending"
+	FileReady				= "ready"
+)
+
+// File folders
+const (
+	FolderListings			= "listings"
+	FolderAvatars			= "avatars"
+	FolderMessages			= "messages"
+)
+
+// Returns a file a user is about to upload to a folder
+func NewUpload(uploaderId uuid.UUID, folder string, contentType string) File {
+	return File{ResourceType: contentType, Folder: folder, UploaderId: &uploaderId, Status: FilePending}
+}
+
+// Returns the key the file is kept under in storage
+func (file File) Key() string {
+	return utils.FileKey(file.ID.String(), file.Folder, file.ResourceType)
+}
+
+// Returns the data the file is uploaded with
+func (file File) UploadData() utils.SignatureFormat {
+	return utils.GenerateFileSignature(file.ID.String(), file.Folder, file.ResourceType)
+}
+
+// Returns a file that replaces the cover of a listing once it's confirmed. It's added to the
+// end of the gallery meanwhile, which also ties it to the listing as relists share covers
+func NewCoverUpload(db *gorm.DB, listing Listing, contentType string) (File, error) {
+	file := NewUpload(listing.AuctioneerId, FolderListings, contentType)
+	file.ReplacesId = &listing.ImageId
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&file).Error; err != nil {
+			return err
+		}
+		var position int
+		tx.Model(&ListingImage{}).Where("listing_id = ?", listing.ID).Select("COALESCE(MAX(position), 0) + 1").Scan(&position)
+		return tx.Create(&ListingImage{ListingId: listing.ID, FileId: file.ID, Position: position}).Error
+	})
+	return file, err
+}
+
+// Marks the file ready and puts it in place of the file it replaces, if any. Avatars replace the
+// uploader's current one and cover uploads the cover of their listing. It returns the replaced files
+// that nothing uses anymore, for the caller to delete
+func (file *File) Confirm(db *gorm.DB) ([]File, error) {
+	if file.Status == FileReady {
+		return nil, nil
+	}
+	replacesId := file.ReplacesId
+	replacedIds := []uuid.UUID{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(file).Updates(map[string]interface{}{"status": FileReady, "replaces_id": nil}).Error; err != nil {
+			return err
+		}
+		if file.Folder == FolderAvatars && file.UploaderId != nil {
+			user := User{}
+			tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&user, *file.UploaderId)
+			if user.AvatarId != nil {
+				replacedIds = append(replacedIds, *user.AvatarId)
+			}
+			return tx.Model(&user).UpdateColumn("avatar_id", file.ID).Error
+		}
+		if replacesId == nil {
+			return nil
+		}
+		image := ListingImage{}
+		tx.Take(&image, "file_id = ?", file.ID)
+		if image.ID == uuid.Nil {
+			return nil
+		}
+		// The new cover takes the old one's place in the gallery
+		oldImage := ListingImage{}
+		tx.Take(&oldImage, "listing_id = ? AND file_id = ?", image.ListingId, *replacesId)
+		if oldImage.ID != uuid.Nil {
+			if err := tx.Model(&image).Update("position", oldImage.Position).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&oldImage).Error; err != nil {
+				return err
+			}
+		}
+		replacedIds = append(replacedIds, *replacesId)
+		return tx.Model(&Listing{}).Unscoped().Where("id = ? AND image_id = ?", image.ListingId, *replacesId).UpdateColumn("image_id", file.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	file.Status = FileReady
+	file.ReplacesId = nil
+
+	unused := []File{}
+	for _, replacedId := range replacedIds {
+		replaced := File{}
+		db.Take(&replaced, replacedId)
+		if replaced.ID != uuid.Nil && !FileInUse(db, replaced.ID) {
+			unused = append(unused, replaced)
+		}
+	}
+	return unused, nil
+}
+
+// Checks if a file is still the avatar, listing image or attachment of anything
+func FileInUse(db *gorm.DB, fileId uuid.UUID) bool {
+	var count int64
+	db.Raw(`SELECT (SELECT COUNT(*) FROM users WHERE avatar_id = @id) +
+		(SELECT COUNT(*) FROM listings WHERE image_id = @id) +
+		(SELECT COUNT(*) FROM listing_images WHERE file_id = @id) +
+		(SELECT COUNT(*) FROM messages WHERE attachment_id = @id)`, sql.Named("id", fileId)).Scan(&count)
+	return count > 0
+}
+
+// Deletes the file from storage, then its row. The row is kept when storage fails so the
+// file can be deleted again later
+func DeleteFile(db *gorm.DB, file File) error {
+	if err := utils.FileStorage().Delete(file.Key()); err != nil {
+		log.Printf("Error deleting file %s from storage: %v", file.ID, err)
+		return err
+	}
+	return db.Delete(&file).Error
+}
+
+// Moves avatars and attachments uploaded before files knew their folder out of the default one
+func SetupFileFolders(db *gorm.DB) {
+	db.Model(&File{}).Where("folder = ? AND id IN (?)", FolderListings, db.Model(&User{}).Select("avatar_id")).Update("folder", FolderAvatars)
+	db.Model(&File{}).Where("folder = ? AND id IN (?)", FolderListings, db.Model(&Message{}).Select("attachment_id")).Update("folder", FolderMessages)
+}
+
+// Returns the pending files that weren't confirmed within the ttl
+func ExpiredUploads(db *gorm.DB, ttl time.Duration) []File {
+	files := []File{}
+	db.Where("status = ? AND updated_at <= ?", FilePending, time.Now().UTC().Add(-ttl)).Find(&files)
+	return files
+}
+
+// Gets the file out of the way of the listings it's the cover of, by making a ready image of
+// each one the cover instead. It returns false, changing nothing, when one of them has no other image
+func ReplaceListingCover(db *gorm.DB, file File) bool {
+	listings := []Listing{}
+	db.Unscoped().Where("image_id = ?", file.ID).Find(&listings)
+	galleries := [][]ListingImage{}
+	for _, listing := range listings {
+		images := []ListingImage{}
+		db.Joins("File").Where(`listing_images.listing_id = ? AND listing_images.file_id != ? AND "File".status = ?`, listing.ID, file.ID, FileReady).
+			Order("listing_images.position ASC, listing_images.created_at ASC").Find(&images)
+		if len(images) == 0 {
+			return false
+		}
+		galleries = append(galleries, images)
+	}
+	for i := range listings {
+		if err := ReorderListingImages(db, &listings[i], galleries[i]); err != nil {
+			log.Println("Failed to replace listing cover:", err)
+			return false
+		}
+	}
+	return true
+}
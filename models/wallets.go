@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Ledger accounts. Users own the available and held accounts, the rest belong to the platform
+const (
+	AccountAvailable		= "available"
+	AccountHeld				= "held"
+	AccountPayout			= "payout"		// withdrawals waiting on the payment provider
+	AccountProvider			= "provider"
+	AccountEscrow			= "escrow"
+)
+
+// Ledger transaction kinds
+const (
+	TransactionDeposit		= "deposit"
+	TransactionWithdrawal	= "withdrawal"
+	TransactionHold			= "hold"
+	TransactionRelease		= "release"
+	TransactionCapture		= "capture"
+)
+
+// Ledger transaction statuses. Only deposits and withdrawals wait on the payment provider
+const (
+	TransactionPending		= "pending"
+	TransactionCompleted	= "completed"
+	TransactionReversed		= "reversed"
+	TransactionFailed		= "failed"
+)
+
+// LEDGER TRANSACTION (a balanced group of entries)
+type LedgerTransaction struct {
+	BaseModel
+	UserId				uuid.UUID			`json:"-" gorm:"not null;index"`
+	User				User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;not null;"`
+	ListingId			*uuid.UUID			`json:"-" gorm:"null"`
+	Listing				*Listing			`json:"-" gorm:"foreignKey:ListingId;constraint:OnDelete:SET NULL;null;"`
+	Kind				string				`json:"kind" gorm:"type:varchar(20);not null" example:"deposit"`
+	Amount				decimal.Decimal		`json:"amount" gorm:"not null" example:"1000.00"`
+	Reference			*string				`json:"reference" gorm:"null" example:"local_4f9a1c2b"`
+	Status				string				`json:"status" gorm:"type:varchar(20);default:completed;not null" example:"completed"`
+	Entries				[]LedgerEntry		`json:"-" gorm:"foreignKey:TransactionId"`
+	Date				time.Time			`json:"date" gorm:"-"`
+}
+
+func (transaction LedgerTransaction) Init() LedgerTransaction {
+	transaction.Date = transaction.CreatedAt
+	return transaction
+}
+
+// LEDGER ENTRY (entries of a transaction always sum up to zero)
+type LedgerEntry struct {
+	BaseModel
+	TransactionId		uuid.UUID			`json:"-" gorm:"not null;index"`
+	Transaction			LedgerTransaction	`json:"-" gorm:"foreignKey:TransactionId;constraint:OnDelete:CASCADE;not null;"`
+	UserId				*uuid.UUID			`json:"-" gorm:"null;index:,composite:user_id_account"`
+	User				*User				`json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE;null;"`
+	Account				string				`json:"account" gorm:"type:varchar(20);not null;index:,composite:user_id_account"`
+	ListingId			*uuid.UUID			`json:"-" gorm:"null;index"`
+	Amount				decimal.Decimal		`json:"amount" gorm:"not null"`
+}
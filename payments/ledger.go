@@ -0,0 +1,265 @@
+package payments
+
+import (
+	"errors"
+	"log"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+var ErrUnbalancedTransaction = errors.New("ledger transaction entries don't balance")
+
+// A single leg of a ledger transaction
+type posting struct {
+	userId				*uuid.UUID
+	account				string
+	listingId			*uuid.UUID
+	amount				decimal.Decimal
+}
+
+// Turns postings into ledger entries, which must sum up to zero
+func entries(postings []posting) ([]models.LedgerEntry, error) {
+	total := decimal.NewFromFloat(0.00)
+	ledgerEntries := []models.LedgerEntry{}
+	for _, p := range postings {
+		total = total.Add(p.amount)
+		ledgerEntries = append(ledgerEntries, models.LedgerEntry{
+			UserId:    p.userId,
+			Account:   p.account,
+			ListingId: p.listingId,
+			Amount:    p.amount.Round(2),
+		})
+	}
+	if !total.IsZero() {
+		return nil, ErrUnbalancedTransaction
+	}
+	return ledgerEntries, nil
+}
+
+// Records a transaction whose entries must sum up to zero
+func post(tx *gorm.DB, transaction models.LedgerTransaction, postings []posting) (models.LedgerTransaction, error) {
+	ledgerEntries, err := entries(postings)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.Entries = ledgerEntries
+	transaction.Amount = transaction.Amount.Round(2)
+	err = tx.Create(&transaction).Error
+	return transaction, err
+}
+
+// Adds the entries (if any) that settle a pending transaction and gives it its final status
+func settle(tx *gorm.DB, transaction *models.LedgerTransaction, status string, reference *string, postings []posting) error {
+	ledgerEntries, err := entries(postings)
+	if err != nil {
+		return err
+	}
+	if len(ledgerEntries) > 0 {
+		for i := range ledgerEntries {
+			ledgerEntries[i].TransactionId = transaction.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&ledgerEntries).Error; err != nil {
+			return err
+		}
+	}
+	transaction.Entries = append(transaction.Entries, ledgerEntries...)
+	transaction.Status = status
+	transaction.Reference = reference
+	return tx.Model(transaction).Updates(map[string]interface{}{"status": status, "reference": reference}).Error
+}
+
+// Serializes balance changes of a user for the rest of the transaction
+func lockUser(tx *gorm.DB, userId uuid.UUID) {
+	tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&models.User{}, userId)
+}
+
+func balance(db *gorm.DB, userId uuid.UUID, account string, listingId *uuid.UUID) decimal.Decimal {
+	total := decimal.NewFromFloat(0.00)
+	query := db.Model(&models.LedgerEntry{}).Where("user_id = ? AND account = ?", userId, account)
+	if listingId != nil {
+		query = query.Where("listing_id = ?", *listingId)
+	}
+	query.Select("COALESCE(SUM(amount), 0)").Scan(&total)
+	return total.Round(2)
+}
+
+// Returns the funds a user can bid with
+func AvailableBalance(db *gorm.DB, userId uuid.UUID) decimal.Decimal {
+	return balance(db, userId, models.AccountAvailable, nil)
+}
+
+// Returns the funds held for a user's bids (on a single listing if given)
+func HeldBalance(db *gorm.DB, userId uuid.UUID, listingId *uuid.UUID) decimal.Decimal {
+	return balance(db, userId, models.AccountHeld, listingId)
+}
+
+// Charges a user through the payment provider and credits their wallet. A pending deposit is
+// committed first and its ID is the reference the provider charges with, so a charge is never
+// made without a ledger record. The deposit is credited once the charge succeeds, or marked failed.
+// Deposits left pending (e.g the server died mid-charge) are checked against the provider on reconciliation
+func Deposit(db *gorm.DB, userId uuid.UUID, amount decimal.Decimal) (models.LedgerTransaction, error) {
+	transaction, err := post(db, models.LedgerTransaction{
+		UserId: userId, Kind: models.TransactionDeposit, Amount: amount, Status: models.TransactionPending,
+	}, []posting{})
+	if err != nil {
+		return transaction, err
+	}
+
+	reference, chargeErr := Provider().Charge(userId, amount, transaction.ID.String())
+	if chargeErr != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return settle(tx, &transaction, models.TransactionFailed, nil, []posting{})
+		})
+		if err != nil {
+			log.Printf("Error failing deposit %s: %s", transaction.ID, err)
+		}
+		return transaction, chargeErr
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return settle(tx, &transaction, models.TransactionCompleted, &reference, []posting{
+			{account: models.AccountProvider, amount: amount.Neg()},
+			{userId: &userId, account: models.AccountAvailable, amount: amount},
+		})
+	})
+	if err != nil {
+		// The money was collected, so the deposit isn't failed. It stays pending until it's reconciled
+		log.Printf("Error completing deposit %s (charge %s): %s", transaction.ID, reference, err)
+	}
+	return transaction, nil
+}
+
+// Pays available funds back to a user through the payment provider. The funds are moved
+// out of the available balance and committed first, so the provider is never called with
+// the user locked and a payout is never made without a ledger record. The withdrawal is
+// completed once the provider pays out, or reversed back to the available balance if it fails.
+// Withdrawals left pending (e.g the server died mid-payout) keep the funds aside for reconciliation
+func Withdraw(db *gorm.DB, userId uuid.UUID, amount decimal.Decimal) (models.LedgerTransaction, error) {
+	transaction := models.LedgerTransaction{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		lockUser(tx, userId)
+		if AvailableBalance(tx, userId).LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+		var err error
+		transaction, err = post(tx, models.LedgerTransaction{
+			UserId: userId, Kind: models.TransactionWithdrawal, Amount: amount, Status: models.TransactionPending,
+		}, []posting{
+			{userId: &userId, account: models.AccountAvailable, amount: amount.Neg()},
+			{userId: &userId, account: models.AccountPayout, amount: amount},
+		})
+		return err
+	})
+	if err != nil {
+		return transaction, err
+	}
+
+	reference, payoutErr := Provider().Payout(userId, amount)
+	if payoutErr != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			return settle(tx, &transaction, models.TransactionReversed, nil, []posting{
+				{userId: &userId, account: models.AccountPayout, amount: amount.Neg()},
+				{userId: &userId, account: models.AccountAvailable, amount: amount},
+			})
+		})
+		if err != nil {
+			log.Printf("Error reversing withdrawal %s: %s", transaction.ID, err)
+		}
+		return transaction, payoutErr
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return settle(tx, &transaction, models.TransactionCompleted, &reference, []posting{
+			{userId: &userId, account: models.AccountPayout, amount: amount.Neg()},
+			{account: models.AccountProvider, amount: amount},
+		})
+	})
+	if err != nil {
+		// The money has left, so the withdrawal isn't failed. It stays pending until it's reconciled
+		log.Printf("Error completing withdrawal %s (payout %s): %s", transaction.ID, reference, err)
+	}
+	return transaction, nil
+}
+
+// Makes sure the given amount is held for a user's bid on a listing, holding only
+// the difference when part of it is already held from an earlier bid
+func HoldForBid(db *gorm.DB, userId uuid.UUID, listingId uuid.UUID, amount decimal.Decimal) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		lockUser(tx, userId)
+		extra := amount.Sub(HeldBalance(tx, userId, &listingId))
+		if !extra.IsPositive() {
+			return nil
+		}
+		if AvailableBalance(tx, userId).LessThan(extra) {
+			return ErrInsufficientFunds
+		}
+		_, err := post(tx, models.LedgerTransaction{
+			UserId: userId, ListingId: &listingId, Kind: models.TransactionHold, Amount: extra,
+		}, []posting{
+			{userId: &userId, account: models.AccountAvailable, amount: extra.Neg()},
+			{userId: &userId, account: models.AccountHeld, listingId: &listingId, amount: extra},
+		})
+		return err
+	})
+}
+
+// Releases everything held for a user's bid on a listing back to their available balance
+func Release(db *gorm.DB, userId uuid.UUID, listingId uuid.UUID) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		lockUser(tx, userId)
+		held := HeldBalance(tx, userId, &listingId)
+		if !held.IsPositive() {
+			return nil
+		}
+		_, err := post(tx, models.LedgerTransaction{
+			UserId: userId, ListingId: &listingId, Kind: models.TransactionRelease, Amount: held,
+		}, []posting{
+			{userId: &userId, account: models.AccountHeld, listingId: &listingId, amount: held.Neg()},
+			{userId: &userId, account: models.AccountAvailable, amount: held},
+		})
+		return err
+	})
+}
+
+// Moves the amount a winner pays from their hold into escrow and releases the rest
+func Capture(db *gorm.DB, userId uuid.UUID, listingId uuid.UUID, amount decimal.Decimal) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		lockUser(tx, userId)
+		if HeldBalance(tx, userId, &listingId).LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+		_, err := post(tx, models.LedgerTransaction{
+			UserId: userId, ListingId: &listingId, Kind: models.TransactionCapture, Amount: amount,
+		}, []posting{
+			{userId: &userId, account: models.AccountHeld, listingId: &listingId, amount: amount.Neg()},
+			{account: models.AccountEscrow, listingId: &listingId, amount: amount},
+		})
+		if err != nil {
+			return err
+		}
+		return Release(tx, userId, listingId)
+	})
+}
+
+// Releases the holds of every bidder on a listing except the given users
+func ReleaseListingHolds(db *gorm.DB, listingId uuid.UUID, exceptUserIds []uuid.UUID) error {
+	except := map[uuid.UUID]bool{}
+	for _, userId := range exceptUserIds {
+		except[userId] = true
+	}
+	userIds := []uuid.UUID{}
+	db.Model(&models.LedgerEntry{}).Where("account = ? AND listing_id = ?", models.AccountHeld, listingId).Distinct().Pluck("user_id", &userIds)
+	for _, userId := range userIds {
+		if except[userId] {
+			continue
+		}
+		if err := Release(db, userId, listingId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
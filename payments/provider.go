@@ -0,0 +1,42 @@
+package payments
+
+import (
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// PaymentProvider moves money between users and the platform
+type PaymentProvider interface {
+	// Collects money from a user, returning the provider's reference. The given reference
+	// identifies the charge on our side, so retrying it doesn't charge the user twice
+	Charge(userId uuid.UUID, amount decimal.Decimal, reference string) (string, error)
+	// Sends money back to a user, returning the provider's reference
+	Payout(userId uuid.UUID, amount decimal.Decimal) (string, error)
+}
+
+// LocalProvider is a fake provider for development and tests. Every payment succeeds
+type LocalProvider struct{}
+
+func (LocalProvider) Charge(userId uuid.UUID, amount decimal.Decimal, reference string) (string, error) {
+	return fmt.Sprintf("local_charge_%s", utils.GetRandomString(12)), nil
+}
+
+func (LocalProvider) Payout(userId uuid.UUID, amount decimal.Decimal) (string, error) {
+	return fmt.Sprintf("local_payout_%s", utils.GetRandomString(12)), nil
+}
+
+var provider PaymentProvider = LocalProvider{}
+
+// Returns the payment provider in use
+func Provider() PaymentProvider {
+	return provider
+}
+
+// Replaces the payment provider (e.g with a real gateway)
+func SetProvider(p PaymentProvider) {
+	provider = p
+}
@@ -0,0 +1,67 @@
+package realtime
+
+import (
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Event types pushed to listing subscribers
+const (
+	BidCreated			= "bid.created"
+	HighestBidChanged	= "highest_bid.changed"
+	ListingExtended		= "listing.extended"
+	ListingClosed		= "listing.closed"
+	ListingCancelled	= "listing.cancelled"
+	ListingUpdated		= "listing.updated"
+	ListingRelisted		= "listing.relisted"
+)
+
+type Event struct {
+	Type				string					`json:"type"`
+	ListingId			uuid.UUID				`json:"listing_id"`
+	Data				map[string]interface{}	`json:"data"`
+}
+
+// Keeps track of the local subscribers of each listing
+type hub struct {
+	mu					sync.RWMutex
+	subscribers			map[uuid.UUID]map[chan Event]struct{}
+}
+
+var listingsHub = hub{subscribers: map[uuid.UUID]map[chan Event]struct{}{}}
+
+// Subscribes to the events of a listing. The returned function must be called
+// once the subscriber goes away
+func Subscribe(listingId uuid.UUID) (<-chan Event, func()) {
+	events := make(chan Event, 16)
+	listingsHub.mu.Lock()
+	if listingsHub.subscribers[listingId] == nil {
+		listingsHub.subscribers[listingId] = map[chan Event]struct{}{}
+	}
+	listingsHub.subscribers[listingId][events] = struct{}{}
+	listingsHub.mu.Unlock()
+
+	unsubscribe := func() {
+		listingsHub.mu.Lock()
+		defer listingsHub.mu.Unlock()
+		delete(listingsHub.subscribers[listingId], events)
+		if len(listingsHub.subscribers[listingId]) == 0 {
+			delete(listingsHub.subscribers, listingId)
+		}
+	}
+	return events, unsubscribe
+}
+
+// Delivers an event to the local subscribers of its listing. Slow subscribers
+// miss events instead of blocking everybody else
+func dispatch(event Event) {
+	listingsHub.mu.RLock()
+	defer listingsHub.mu.RUnlock()
+	for events := range listingsHub.subscribers[event.ListingId] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
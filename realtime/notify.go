@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Postgres channel used to fan events out across instances
+const channel = "listing_events"
+
+var listening atomic.Bool
+
+// Publishes a listing event to every instance. When this instance isn't listening
+// on postgres (e.g in tests), the event is delivered locally only
+func Publish(db *gorm.DB, listingId uuid.UUID, eventType string, data map[string]interface{}) {
+	event := Event{Type: eventType, ListingId: listingId, Data: data}
+	if !listening.Load() {
+		dispatch(event)
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Println("Error encoding event: ", err)
+		return
+	}
+	if result := db.Exec("SELECT pg_notify(?, ?)", channel, string(payload)); result.Error != nil {
+		log.Println("Error publishing event: ", result.Error)
+	}
+}
+
+// Listens for events published by any instance and delivers them to local subscribers.
+// The connection is re-established whenever it drops
+func Listen(dsn string) {
+	listening.Store(true)
+	go func() {
+		for {
+			if err := listen(dsn); err != nil {
+				log.Println("Realtime listener error: ", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+	}()
+}
+
+func listen(dsn string) error {
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		event := Event{}
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Println("Error decoding event: ", err)
+			continue
+		}
+		dispatch(event)
+	}
+}
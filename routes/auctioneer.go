@@ -1,11 +1,19 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/kayprogrammer/bidout-auction-v7/auctions"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
 	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/senders"
 	"github.com/kayprogrammer/bidout-auction-v7/utils"
 	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -33,7 +41,7 @@ func GetProfile(c *fiber.Ctx) error {
 }
 
 // @Summary Update Profile
-// @Description This endpoint updates an authenticated user's profile. Note: use the returned upload_url to upload avatar to cloudinary
+// @Description This endpoint updates an authenticated user's profile. Note: use the returned upload_url to upload the avatar, which replaces the current one once the upload is confirmed
 // @Tags Auctioneer
 // @Param user body schemas.UpdateProfileSchema true "Update User"
 // @Success 200 {object} schemas.UpdateProfileResponseSchema
@@ -55,16 +63,14 @@ func UpdateProfile(c *fiber.Ctx) error {
 		return c.Status(422).JSON(err)
 	}
 
+	// The new avatar replaces the current one once its upload is confirmed
+	fileUploadData := user.GetAvatarUploadUrl(db)
 	fileType := updateProfileData.FileType
 	if fileType != nil {
-		file := models.File{ResourceType: *fileType}
-		if user.AvatarId == nil {
-			db.Create(&file)
-		} else {
-			db.Model(models.File{BaseModel: models.BaseModel{ID: *user.AvatarId}}).Updates(&file)
-			file.ID = *user.AvatarId
-		}
-		user.AvatarId = &file.ID
+		file := models.NewUpload(user.ID, models.FolderAvatars, *fileType)
+		db.Create(&file)
+		uploadData := file.UploadData()
+		fileUploadData = &uploadData
 	}
 	user.FirstName = updateProfileData.FirstName
 	user.LastName = updateProfileData.LastName
@@ -73,7 +79,7 @@ func UpdateProfile(c *fiber.Ctx) error {
 	userData := schemas.UpdateProfileResponseDataSchema{
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
-		FileUploadData: user.GetAvatarUploadUrl(db),
+		FileUploadData: fileUploadData,
 	}
 	response := schemas.UpdateProfileResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "User updated!"}.Init(),
@@ -83,37 +89,165 @@ func UpdateProfile(c *fiber.Ctx) error {
 }
 
 // @Summary Retrieve all listings by the current user
-// @Description This endpoint retrieves all listings by the current user.
+// @Description This endpoint retrieves the current user's listings a page at a time. Pass the returned meta.next_cursor as 'cursor' to get the next page. Pass archived=true to get deleted listings instead.
 // @Tags Auctioneer
-// @Param quantity query int false  "Listings Quantity"
-// @Success 200 {object} schemas.ListingsResponseSchema
+// @Param cursor query string false  "Page Cursor"
+// @Param limit query int false  "Page Size (max 100)"
+// @Param quantity query int false  "Listings Quantity (same as limit)"
+// @Param category query string false  "Category Slug (use 'other' for uncategorized listings)"
+// @Param min_price query number false  "Minimum Price"
+// @Param max_price query number false  "Maximum Price"
+// @Param status query string false  "Auction Status (upcoming, live or ended)"
+// @Param state query string false  "Listing State (draft, pending_review, published, rejected, cancelled or ended)"
+// @Param archived query bool false  "Archived (deleted) listings only"
+// @Param sort query string false  "Sort (newest, ending_soon, price_asc, price_desc or most_bids)"
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
+// @Success 200 {object} schemas.PaginatedListingsResponseSchema
+// @Failure 422 {object} utils.ErrorResponse
 // @Router /auctioneer/listings [get]
 // @Security BearerAuth
 func GetAuctioneerListings(c *fiber.Ctx) error {
 	db := c.Locals("db").(*gorm.DB)
 	user := c.Locals("user").(*models.User)
 
-	listings := []models.Listing{}
-	quantity := c.QueryInt("quantity")
+	query, errData := ListingQueryFromRequest(c, db)
+	if errData != nil {
+		return c.Status(422).JSON(errData)
+	}
+	query.AuctioneerId = &user.ID
+
 	// Get listings
-	db.Preload(clause.Associations).Order("created_at DESC").Find(&listings, models.Listing{AuctioneerId: user.ID})
+	listings, meta, errData := PaginateListings(db, query)
+	if errData != nil {
+		return c.Status(422).JSON(errData)
+	}
 
 	// Initialize each listing object in the slice
+	currency := DisplayCurrency(c)
 	for i := range listings {
-		listings[i] = listings[i].Init(db)
-	}
-	if quantity > 0 {
-		listings = listings[:quantity]
+		listings[i] = listings[i].Init(db).InCurrency(db, currency)
 	}
-	response := schemas.ListingsResponseSchema{
+	response := schemas.PaginatedListingsResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Auctioneer Listings fetched"}.Init(),
 		Data:           listings,
+		Meta:           meta,
 	}
 	return c.Status(200).JSON(response)
 }
 
+// Checks the parts of a new listing the validator can't, then builds it. Nothing is saved
+func newListingFromSchema(db *gorm.DB, user *models.User, createListingData schemas.CreateListingSchema) (*models.Listing, map[string]string) {
+	var categoryId *uuid.UUID
+	categorySlug := createListingData.Category
+	// Validate Category
+	if categorySlug != "other" {
+		category := models.Category{Slug: &categorySlug}
+		db.Take(&category, category)
+		if category.ID == uuid.Nil {
+			return nil, map[string]string{"category": "Invalid category!"}
+		}
+		categoryId = &category.ID
+	}
+	auctionType := createListingData.AuctionType
+	if auctionType == "" {
+		auctionType = models.AuctionEnglish
+	}
+	if createListingData.BuyNowPrice != nil && !buyNowSupported(auctionType) {
+		return nil, map[string]string{"buy_now_price": "Buy now is not available for this auction type!"}
+	}
+	currency := createListingData.Currency
+	if currency == "" {
+		currency = models.BaseCurrency()
+	}
+	if !models.CurrencySupported(db, currency) {
+		return nil, map[string]string{"currency": "Unsupported currency!"}
+	}
+	quantity := 1
+	if createListingData.Quantity != nil {
+		quantity = *createListingData.Quantity
+	}
+	if quantity > 1 {
+		if auctionType == models.AuctionDutch || auctionType == models.AuctionReverse {
+			return nil, map[string]string{"quantity": "Multi-quantity lots are not available for this auction type!"}
+		} else if createListingData.BuyNowPrice != nil {
+			return nil, map[string]string{"buy_now_price": "Buy now is not available for multi-quantity lots!"}
+		}
+	}
+	pricingRule := createListingData.PricingRule
+	if pricingRule == "" {
+		pricingRule = models.PricingDiscriminatory
+	}
+	if createListingData.RequiresDeposit && auctionType == models.AuctionReverse {
+		return nil, map[string]string{"requires_deposit": "Deposits are not available for reverse auctions!"}
+	}
+	if createListingData.RequiresDeposit && currency != models.BaseCurrency() {
+		// Wallets are kept in the base currency
+		return nil, map[string]string{"requires_deposit": fmt.Sprintf("Deposits are only available for listings in %s!", models.BaseCurrency())}
+	}
+
+	var startsAt *time.Time
+	if createListingData.StartsAt != nil {
+		parsedStartsAt := utils.TimeParser(*createListingData.StartsAt)
+		if !parsedStartsAt.Before(utils.TimeParser(createListingData.ClosingDate)) {
+			return nil, map[string]string{"starts_at": "Start date must be before the closing date!"}
+		}
+		startsAt = &parsedStartsAt
+	}
+
+	attributes, attributeErrors := models.ValidateListingAttributes(models.CategoryAttributes(db, categoryId), createListingData.Attributes)
+	if attributeErrors != nil {
+		return nil, attributeErrors
+	}
+
+	listing := models.Listing{
+		AuctioneerId: user.ID,
+		Name:         createListingData.Name,
+		Desc:         createListingData.Desc,
+		CategoryId:   categoryId,
+		Active:       true,
+		AuctionType:  auctionType,
+		RequiresDeposit: createListingData.RequiresDeposit,
+		Currency:     currency,
+		Quantity:     quantity,
+		PricingRule:  pricingRule,
+		Price:        utils.DecimalParser(createListingData.Price),
+		StartsAt:     startsAt,
+		ClosingDate:  utils.TimeParser(createListingData.ClosingDate),
+		Attributes:   attributes,
+		AutoRelist:   createListingData.AutoRelist,
+		State:        models.ListingStatePendingReview,
+	}
+	if createListingData.Draft {
+		listing.State = models.ListingStateDraft
+	}
+	if auctionType == models.AuctionDutch {
+		floorPrice := utils.DecimalParser(*createListingData.FloorPrice)
+		priceDecrement := utils.DecimalParser(*createListingData.PriceDecrement)
+		listing.FloorPrice = &floorPrice
+		listing.PriceDecrement = &priceDecrement
+		listing.DecrementMinutes = createListingData.DecrementMinutes
+	}
+	if createListingData.BuyNowPrice != nil {
+		buyNowPrice := utils.DecimalParser(*createListingData.BuyNowPrice)
+		listing.BuyNowPrice = &buyNowPrice
+	}
+	return &listing, nil
+}
+
+// Saves a new listing along with the file of its cover image, or neither if one fails
+func saveNewListing(db *gorm.DB, listing *models.Listing, fileType string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		file := models.NewUpload(listing.AuctioneerId, models.FolderListings, fileType)
+		if err := tx.Create(&file).Error; err != nil {
+			return err
+		}
+		listing.ImageId = file.ID
+		return tx.Create(listing).Error
+	})
+}
+
 // @Summary Create a listing
-// @Description This endpoint creates a new listing. Note: Use the returned upload_url to upload image to cloudinary
+// @Description This endpoint creates a new listing and submits it for review, or saves it as a draft if draft is true. It's only shown to others once staff approve it. Set starts_at to schedule when bidding opens. Set auto_relist to have it relisted automatically, up to that many times, when it ends without a winner. Note: Use the returned upload_url to upload the image
 // @Tags Auctioneer
 // @Param listing body schemas.CreateListingSchema true "Create Listing"
 // @Success 200 {object} schemas.CreateListingResponseSchema
@@ -134,39 +268,14 @@ func CreateListing(c *fiber.Ctx) error {
 	if err := validator.Validate(createListingData); err != nil {
 		return c.Status(422).JSON(err)
 	}
-	var categoryId *uuid.UUID
-	categorySlug := createListingData.Category
-	// Validate Category
-	if categorySlug != "other" {
-		category := models.Category{Slug: &categorySlug}
-		db.Take(&category, category)
-		if category.ID == uuid.Nil {
-			data := map[string]string{
-				"category": "Invalid category!",
-			}
-			return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
-		}
-		categoryId = &category.ID
-
-	} else {
-		categoryId = nil
+	listing, errData := newListingFromSchema(db, user, createListingData)
+	if errData != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &errData}.Init())
 	}
-	fileType := createListingData.FileType
-	file := models.File{ResourceType: fileType}
-	db.Create(&file)
-
-	listing := models.Listing{
-		AuctioneerId: user.ID,
-		Name:         createListingData.Name,
-		Desc:         createListingData.Desc,
-		CategoryId:   categoryId,
-		Active:       true,
-		Price:        utils.DecimalParser(createListingData.Price),
-		ClosingDate:  utils.TimeParser(createListingData.ClosingDate),
-		ImageId:      file.ID,
+	if err := saveNewListing(db, listing, createListingData.FileType); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
 	}
-	db.Create(&listing)
-	db.Preload(clause.Associations).Take(&listing, listing.ID)
+	db.Preload(clause.Associations).Take(listing, listing.ID)
 
 	listingData := schemas.CreateListingResponseDataSchema{
 		Listing:        listing.Init(db),
@@ -180,7 +289,7 @@ func CreateListing(c *fiber.Ctx) error {
 }
 
 // @Summary Update a listing
-// @Description This endpoint updates a particular listing. Note: Use the returned upload_url to upload image to cloudinary
+// @Description This endpoint updates a particular listing. Set remove_buy_now to take the buy now option off and auto_relist to change how many times it relists itself. Once the listing has bids, its price, closing_date, buy_now_price and category can no longer be changed. Name and description edits to a live listing are kept in its revision history and bidders are told about them. Note: Use the returned upload_url to upload the image, which replaces the cover once the upload is confirmed
 // @Tags Auctioneer
 // @Param slug path string true  "Listing Slug"
 // @Param listing body schemas.UpdateListingSchema true "Update Listing"
@@ -203,6 +312,10 @@ func UpdateListing(c *fiber.Ctx) error {
 	if listing.AuctioneerId != user.ID {
 		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing doesn't belong to you!"}.Init())
 	}
+	if listing.State == models.ListingStateCancelled || listing.State == models.ListingStateEnded {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing can no longer be edited!"}.Init())
+	}
+	original := listing
 
 	updateListingData := schemas.UpdateListingSchema{}
 
@@ -233,20 +346,166 @@ func UpdateListing(c *fiber.Ctx) error {
 		}
 	}
 
-	fileType := updateListingData.FileType
-	if fileType != nil {
-		file := models.File{ResourceType: *fileType}
-		db.Model(models.File{BaseModel: models.BaseModel{ID: listing.ImageId}}).Updates(&file)
+	if updateListingData.Attributes != nil || categorySlug != nil {
+		// Sent attributes replace the current ones. Either way they must suit the category
+		attributeValues := listing.Attributes
+		if updateListingData.Attributes != nil {
+			attributeValues = updateListingData.Attributes
+		}
+		attributes, attributeErrors := models.ValidateListingAttributes(models.CategoryAttributes(db, listing.CategoryId), attributeValues)
+		if attributeErrors != nil {
+			return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &attributeErrors}.Init())
+		}
+		listing.Attributes = attributes
+	}
+
+	if updateListingData.StartsAt != nil && !listing.IsUpcoming() {
+		data := map[string]string{
+			"starts_at": "This auction has already started!",
+		}
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
 	}
 
-	// Assign data to listing
+	// Assign data to listing (auto_relist included, so sellers can turn relisting on or off)
 	utils.AssignFields(updateListingData, &listing)
-	db.Save(&listing)
+	if updateListingData.RemoveBuyNow != nil && *updateListingData.RemoveBuyNow {
+		if updateListingData.BuyNowPrice != nil {
+			return c.Status(422).JSON(invalidEntry("remove_buy_now", "Can't set and remove the buy now price at once!"))
+		}
+		listing.BuyNowPrice = nil
+	}
+	if listing.StartsAt != nil && !listing.StartsAt.Before(listing.ClosingDate) {
+		data := map[string]string{
+			"starts_at": "Start date must be before the closing date!",
+		}
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
+	}
+	if listing.BuyNowPrice != nil {
+		data := map[string]string{}
+		if !buyNowSupported(listing.AuctionType) {
+			data["buy_now_price"] = "Buy now is not available for this auction type!"
+		} else if listing.IsMultiUnit() {
+			data["buy_now_price"] = "Buy now is not available for multi-quantity lots!"
+		} else if listing.BuyNowPrice.Cmp(listing.Price) <= 0 {
+			data["buy_now_price"] = "Buy now price must be more than the price!"
+		}
+		if len(data) > 0 {
+			return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
+		}
+	}
+
+	// Only the sent fields are written, so columns changed by others meanwhile are kept
+	changedFields := []string{}
+	for field, sent := range map[string]bool{
+		"Name":        updateListingData.Name != nil,
+		"Slug":        updateListingData.Name != nil,
+		"Desc":        updateListingData.Desc != nil,
+		"CategoryId":  categorySlug != nil,
+		"Attributes":  updateListingData.Attributes != nil || categorySlug != nil,
+		"Price":       updateListingData.Price != nil,
+		"StartsAt":    updateListingData.StartsAt != nil,
+		"ClosingDate": updateListingData.ClosingDate != nil,
+		"Active":      updateListingData.Active != nil,
+		"BuyNowPrice": updateListingData.BuyNowPrice != nil || updateListingData.RemoveBuyNow != nil,
+		"AutoRelist":  updateListingData.AutoRelist != nil,
+	} {
+		if sent {
+			changedFields = append(changedFields, field)
+		}
+	}
+
+	// Re-read the listing, check for bids and save under a lock on the listing, which bids take
+	// too, so a bid can't come in between and leave it with changes bidders didn't agree to
+	hasBids := false
+	closed := false
+	bidErrData := map[string]string{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		locked := models.Listing{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&locked, listing.ID).Error; err != nil {
+			return err
+		}
+		if locked.State == models.ListingStateCancelled || locked.State == models.ListingStateEnded {
+			closed = true
+			return nil
+		}
+		original = locked
+		// Fields that weren't sent keep their current values, e.g a closing date extended by a bid
+		if updateListingData.Price == nil {
+			listing.Price = locked.Price
+		}
+		if updateListingData.ClosingDate == nil {
+			listing.ClosingDate = locked.ClosingDate
+		}
+		if updateListingData.BuyNowPrice == nil && updateListingData.RemoveBuyNow == nil {
+			listing.BuyNowPrice = locked.BuyNowPrice
+		}
+		if categorySlug == nil {
+			listing.CategoryId = locked.CategoryId
+		}
+		hasBids = locked.HasBids(tx)
+		if hasBids {
+			if updateListingData.Active != nil && !*updateListingData.Active && original.Active {
+				bidErrData["active"] = "Listings with bids can't be deactivated. Cancel the listing instead!"
+				return nil
+			}
+			// Bidders committed to these, so they're fixed once bidding starts
+			for _, field := range models.BidLockedChanges(original, listing) {
+				bidErrData[field] = "This can't be changed once the listing has bids!"
+			}
+			if len(bidErrData) > 0 {
+				return nil
+			}
+		}
+		if len(changedFields) == 0 {
+			return nil
+		}
+		return tx.Model(&listing).Select(changedFields).Updates(&listing).Error
+	})
+	if closed {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing can no longer be edited!"}.Init())
+	}
+	if len(bidErrData) > 0 {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &bidErrData}.Init())
+	}
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
 	db.Preload(clause.Associations).Take(&listing, listing.ID)
 
+	// The new cover replaces the current one once its upload is confirmed
+	fileUploadData := listing.GetImageUploadData(db)
+	if updateListingData.FileType != nil {
+		file, err := models.NewCoverUpload(db, listing, *updateListingData.FileType)
+		if err != nil {
+			return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+		}
+		fileUploadData = file.UploadData()
+	}
+
+	if !listing.ClosingDate.Equal(original.ClosingDate) {
+		realtime.Publish(db, listing.ID, realtime.ListingExtended, map[string]interface{}{
+			"closing_date":      listing.ClosingDate.UTC(),
+			"time_left_seconds": listing.TimeLeftSeconds(),
+		})
+	}
+	if listing.IsPublic() {
+		// Edits to live listings stay visible to everyone
+		models.RecordListingRevisions(db, original, listing)
+		if changes := models.ListingDetailChanges(original, listing); len(changes) > 0 {
+			realtime.Publish(db, listing.ID, realtime.ListingUpdated, map[string]interface{}{
+				"changes": changes,
+				"name":    listing.Name,
+				"desc":    listing.Desc,
+			})
+			if hasBids {
+				go senders.NotifyListingUpdated(c.Locals("env"), db, listing, changes)
+			}
+		}
+	}
+
 	listingData := schemas.CreateListingResponseDataSchema{
 		Listing:        listing.Init(db),
-		FileUploadData: listing.GetImageUploadData(db),
+		FileUploadData: fileUploadData,
 	}
 	response := schemas.CreateListingResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Listing updated successfully"}.Init(),
@@ -255,6 +514,416 @@ func UpdateListing(c *fiber.Ctx) error {
 	return c.Status(200).JSON(response)
 }
 
+// @Summary Submit a listing for review
+// @Description This endpoint submits a draft or rejected listing to staff for review.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Success 200 {object} schemas.ListingResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/submit [post]
+// @Security BearerAuth
+func SubmitListing(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if listing.State == models.ListingStatePendingReview || !listing.CanTransitionTo(models.ListingStatePendingReview) {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "Only drafts and rejected listings can be submitted for review!"}.Init())
+	}
+	db.Model(listing).Updates(map[string]interface{}{"state": models.ListingStatePendingReview, "rejection_reason": nil})
+	db.Preload(clause.Associations).Take(listing, listing.ID)
+
+	response := schemas.ListingResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing submitted for review"}.Init(),
+		Data:           listing.Init(db),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Cancel a listing
+// @Description This endpoint cancels a listing that hasn't ended. Bidders and watchers are emailed the reason and any deposits held for the listing are released.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Param reason body schemas.CancelListingSchema true "Cancellation"
+// @Success 200 {object} schemas.ListingResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/cancel [post]
+// @Security BearerAuth
+func CancelListing(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+
+	cancelData := schemas.CancelListingSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &cancelData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(cancelData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	if cancelErr := auctions.Cancel(db, listing.ID, cancelData.Reason); cancelErr != nil {
+		return c.Status(cancelErr.Code).JSON(utils.ErrorResponse{Message: cancelErr.Message}.Init())
+	}
+	db.Preload(clause.Associations).Take(listing, listing.ID)
+	go senders.NotifyListingCancelled(c.Locals("env"), db, *listing)
+
+	response := schemas.ListingResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing cancelled"}.Init(),
+		Data:           listing.Init(db),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Relist a listing
+// @Description This endpoint copies an ended listing's details, category, attributes, auction settings and images into a new draft closing at the given date. Pass price to start the draft at a different price. A listing can only be relisted once, unless its copy is deleted. The draft goes through review like any other once submitted.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Param relist body schemas.RelistListingSchema true "Relist Listing"
+// @Success 201 {object} schemas.ListingResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/relist [post]
+// @Security BearerAuth
+func RelistListing(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if !listing.CanRelist() {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "Only ended listings can be relisted!"}.Init())
+	} else if listing.WasRelisted(db) {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing has been relisted already!"}.Init())
+	}
+
+	relistData := schemas.RelistListingSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &relistData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(relistData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	closingDate := utils.TimeParser(relistData.ClosingDate)
+	var startsAt *time.Time
+	if relistData.StartsAt != nil {
+		parsedStartsAt := utils.TimeParser(*relistData.StartsAt)
+		if !parsedStartsAt.Before(closingDate) {
+			return c.Status(422).JSON(invalidEntry("starts_at", "Start date must be before the closing date!"))
+		}
+		startsAt = &parsedStartsAt
+	}
+	var price *decimal.Decimal
+	if relistData.Price != nil {
+		parsedPrice := utils.DecimalParser(*relistData.Price)
+		if listing.BuyNowPrice != nil && parsedPrice.Cmp(*listing.BuyNowPrice) >= 0 {
+			return c.Status(422).JSON(invalidEntry("price", "Price must be less than the buy now price!"))
+		}
+		if listing.FloorPrice != nil && parsedPrice.Cmp(*listing.FloorPrice) <= 0 {
+			return c.Status(422).JSON(invalidEntry("price", "Price must be more than the floor price!"))
+		}
+		price = &parsedPrice
+	}
+
+	relist, err := listing.Relist(db, closingDate, startsAt, price)
+	if errors.Is(err, models.ErrAlreadyRelisted) {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing has been relisted already!"}.Init())
+	} else if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	db.Preload(clause.Associations).Take(relist, relist.ID)
+
+	response := schemas.ListingResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing relisted successfully"}.Init(),
+		Data:           relist.Init(db).WithImages(db),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Delete a listing
+// @Description This endpoint archives a listing. It's hidden from everyone but the owner (see archived=true on the listings endpoint) and its bids are kept. Published listings must be cancelled first.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Success 200 {object} schemas.ResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug} [delete]
+// @Security BearerAuth
+func DeleteListing(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if listing.State == models.ListingStatePublished {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "Cancel this listing before deleting it!"}.Init())
+	}
+	db.Delete(listing)
+	return c.Status(200).JSON(schemas.ResponseSchema{Message: "Listing deleted successfully"}.Init())
+}
+
+// Gets a listing of the current user from the 'slug' path param
+func getAuctioneerListing(c *fiber.Ctx, db *gorm.DB, user *models.User) (*models.Listing, int, *utils.ErrorResponse) {
+	listingSlug := c.Params("slug")
+	listing := models.Listing{Slug: &listingSlug}
+	db.Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		errResp := utils.ErrorResponse{Message: "Invalid listing!"}.Init()
+		return nil, 404, &errResp
+	}
+	if listing.AuctioneerId != user.ID {
+		errResp := utils.ErrorResponse{Message: "This listing doesn't belong to you!"}.Init()
+		return nil, 400, &errResp
+	}
+	return &listing, 0, nil
+}
+
+// Returns the listing's gallery with the upload data of every image
+func listingImagesResponseData(db *gorm.DB, listing models.Listing) []schemas.ListingImageResponseDataSchema {
+	images := listing.GetAllImages(db)
+	imagesData := []schemas.ListingImageResponseDataSchema{}
+	for _, image := range images {
+		imagesData = append(imagesData, schemas.ListingImageResponseDataSchema{ListingImage: image, FileUploadData: image.GetUploadData()})
+	}
+	return imagesData
+}
+
+// @Summary Add images to a listing
+// @Description This endpoint adds images to the end of a listing's gallery. Note: Use the returned file_upload_data to upload each image
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Param images body schemas.AddListingImagesSchema true "Add Listing Images"
+// @Success 201 {object} schemas.ListingImagesResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/images [post]
+// @Security BearerAuth
+func AddListingImages(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+
+	imagesData := schemas.AddListingImagesSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &imagesData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(imagesData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	if len(imagesData.FileTypes) == 0 {
+		return c.Status(422).JSON(invalidEntry("file_types", "This field is required."))
+	}
+
+	// Count the gallery under a lock on the listing so concurrent requests can't go over the limit
+	tooManyImages := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&models.Listing{}, listing.ID).Error; err != nil {
+			return err
+		}
+		var imagesCount int64
+		if err := tx.Model(&models.ListingImage{}).Where("listing_id = ?", listing.ID).Count(&imagesCount).Error; err != nil {
+			return err
+		}
+		if int(imagesCount)+len(imagesData.FileTypes) > models.MaxListingImages {
+			tooManyImages = true
+			return nil
+		}
+		for i, fileType := range imagesData.FileTypes {
+			file := models.NewUpload(user.ID, models.FolderListings, fileType)
+			if err := tx.Create(&file).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.ListingImage{ListingId: listing.ID, FileId: file.ID, Position: int(imagesCount) + i}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	if tooManyImages {
+		return c.Status(422).JSON(invalidEntry("file_types", fmt.Sprintf("A listing can have at most %d images!", models.MaxListingImages)))
+	}
+
+	response := schemas.ListingImagesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Images added successfully"}.Init(),
+		Data:           listingImagesResponseData(db, *listing),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Reorder the images of a listing
+// @Description This endpoint sets the order of a listing's gallery. All the image ids must be passed and the first one becomes the cover image.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Param images body schemas.ReorderListingImagesSchema true "Reorder Listing Images"
+// @Success 200 {object} schemas.ListingImagesResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/images [put]
+// @Security BearerAuth
+func ReorderListingImages(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+
+	reorderData := schemas.ReorderListingImagesSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &reorderData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(reorderData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	// Re-read the listing and check the order against its gallery under the lock that adding
+	// images takes, so images added or removed meanwhile can't be left out of it
+	imageIdsErr := ""
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(listing, listing.ID).Error; err != nil {
+			return err
+		}
+		images := listing.GetAllImages(tx)
+		imagesById := map[string]models.ListingImage{}
+		for _, image := range images {
+			imagesById[image.ID.String()] = image
+		}
+		orderedImages := []models.ListingImage{}
+		for _, imageId := range reorderData.ImageIds {
+			image, ok := imagesById[imageId]
+			if !ok {
+				imageIdsErr = fmt.Sprintf("Invalid image id: %s", imageId)
+				return nil
+			}
+			delete(imagesById, imageId)
+			orderedImages = append(orderedImages, image)
+		}
+		if len(orderedImages) != len(images) {
+			imageIdsErr = "Pass every image of the listing once!"
+			return nil
+		}
+		return models.ReorderListingImages(tx, listing, orderedImages)
+	})
+	if imageIdsErr != "" {
+		return c.Status(422).JSON(invalidEntry("image_ids", imageIdsErr))
+	}
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+
+	response := schemas.ListingImagesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Images reordered successfully"}.Init(),
+		Data:           listingImagesResponseData(db, *listing),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Remove an image from a listing
+// @Description This endpoint removes an image from a listing's gallery. If the cover image is removed, the next image becomes the cover.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Param id path string true  "Image ID"
+// @Success 200 {object} schemas.ListingImagesResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/images/{id} [delete]
+// @Security BearerAuth
+func RemoveListingImage(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+
+	imageId := uuid.FromStringOrNil(c.Params("id"))
+
+	// Re-read the listing and its gallery under the lock that adding images takes. The cover is moved
+	// before deleting its file since the listing references it
+	var removedImage *models.ListingImage
+	removeErrCode, removeErrMessage := 0, ""
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(listing, listing.ID).Error; err != nil {
+			return err
+		}
+		images := listing.GetAllImages(tx)
+		remainingImages := []models.ListingImage{}
+		for i := range images {
+			if images[i].ID == imageId {
+				removedImage = &images[i]
+			} else {
+				remainingImages = append(remainingImages, images[i])
+			}
+		}
+		if removedImage == nil {
+			removeErrCode, removeErrMessage = 404, "Image does not exist!"
+			return nil
+		}
+		if len(remainingImages) == 0 {
+			removeErrCode, removeErrMessage = 400, "A listing must have at least one image!"
+			return nil
+		}
+		if err := models.ReorderListingImages(tx, listing, remainingImages); err != nil {
+			return err
+		}
+		return tx.Delete(removedImage).Error
+	})
+	if removeErrCode != 0 {
+		return c.Status(removeErrCode).JSON(utils.ErrorResponse{Message: removeErrMessage}.Init())
+	}
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	// Relists share images, so the file is only deleted once nothing uses it. Storage errors are
+	// logged and the file is kept, so it's just left over rather than failing the removal
+	if !models.FileInUse(db, removedImage.FileId) {
+		models.DeleteFile(db, removedImage.File)
+	}
+
+	response := schemas.ListingImagesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Image removed successfully"}.Init(),
+		Data:           listingImagesResponseData(db, *listing),
+	}
+	return c.Status(200).JSON(response)
+}
+
 // @Summary Retrieve bids in a listing (current user)
 // @Description This endpoint retrieves all bids in a particular listing by the current user.
 // @Tags Auctioneer
@@ -269,8 +938,9 @@ func GetAuctioneerListingBids(c *fiber.Ctx) error {
 
 	listingSlug := c.Params("slug")
 
+	// Owners can still see the bids of archived listings
 	listing := models.Listing{Slug: &listingSlug}
-	db.Preload("Bids", func(db *gorm.DB) *gorm.DB {
+	db.Unscoped().Preload("Bids", func(db *gorm.DB) *gorm.DB {
 		return db.Order("updated_at DESC").Limit(3) // Order by updated
 	}).Find(&listing, listing)
 	if listing.ID == uuid.Nil {
@@ -284,6 +954,9 @@ func GetAuctioneerListingBids(c *fiber.Ctx) error {
 	bids := listing.Bids
 	for i := range bids {
 		bids[i] = bids[i].Init(db)
+		if listing.IsSealed() && !listing.IsClosed() {
+			bids[i] = bids[i].Seal()
+		}
 	}
 
 	response := schemas.BidsResponseSchema{
@@ -292,3 +965,131 @@ func GetAuctioneerListingBids(c *fiber.Ctx) error {
 	}
 	return c.Status(200).JSON(response)
 }
+
+// Returns a user's preference for every notification kind
+func getNotificationPreferences(db *gorm.DB, user *models.User) []models.NotificationPreference {
+	preferences := []models.NotificationPreference{}
+	for _, kind := range models.NotificationKinds() {
+		preferences = append(preferences, models.NotificationPreference{
+			Kind:    kind,
+			Enabled: models.NotificationEnabled(db, user.ID, kind),
+		})
+	}
+	return preferences
+}
+
+// @Summary Get notification preferences
+// @Description This endpoint retrieves the notifications the current user receives.
+// @Tags Auctioneer
+// @Success 200 {object} schemas.NotificationPreferencesResponseSchema
+// @Router /auctioneer/notifications [get]
+// @Security BearerAuth
+func GetNotificationPreferences(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	response := schemas.NotificationPreferencesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Notification preferences fetched"}.Init(),
+		Data:           getNotificationPreferences(db, user),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Update notification preferences
+// @Description This endpoint opts the current user in or out of notification kinds (e.g outbid, ending_soon).
+// @Tags Auctioneer
+// @Param preferences body schemas.UpdateNotificationPreferencesSchema true "Notification Preferences"
+// @Success 200 {object} schemas.NotificationPreferencesResponseSchema
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/notifications [put]
+// @Security BearerAuth
+func UpdateNotificationPreferences(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	preferencesData := schemas.UpdateNotificationPreferencesSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &preferencesData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(preferencesData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	kinds := map[string]bool{}
+	for _, kind := range models.NotificationKinds() {
+		kinds[kind] = true
+	}
+	for kind := range preferencesData.Preferences {
+		if !kinds[kind] {
+			data := map[string]string{
+				"preferences": fmt.Sprintf("Invalid notification kind: %s", kind),
+			}
+			return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
+		}
+	}
+
+	// Create or update
+	for kind, enabled := range preferencesData.Preferences {
+		preference := models.NotificationPreference{UserId: user.ID, Kind: kind}
+		db.Take(&preference, preference)
+		preference.Enabled = enabled
+		db.Save(&preference)
+	}
+
+	response := schemas.NotificationPreferencesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Notification preferences updated"}.Init(),
+		Data:           getNotificationPreferences(db, user),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// Buy now only makes sense for auctions where bids go up in the open
+func buyNowSupported(auctionType string) bool {
+	return auctionType == models.AuctionEnglish
+}
+
+// @Summary Retrieve listing analytics
+// @Description This endpoint retrieves the impressions, views, unique viewers, watchers, bids and conversion rate (bidders per unique viewer) of the current user's listings, with a breakdown by day. Counts are collected in batches, so the latest minute may be missing.
+// @Tags Auctioneer
+// @Param days query int false  "Number of days to cover (max 90, default 30)"
+// @Param listing query string false  "Listing Slug (all listings by default)"
+// @Success 200 {object} schemas.AnalyticsResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/analytics [get]
+// @Security BearerAuth
+func GetAuctioneerAnalytics(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	queryData := schemas.AnalyticsQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid query params!"}.Init())
+	}
+	if err := utils.Validator().Validate(queryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	days := queryData.Days
+	if days == 0 {
+		days = 30
+	}
+
+	listings := []models.Listing{}
+	listingsQuery := db.Where("auctioneer_id = ?", user.ID).Order("created_at DESC")
+	if queryData.Listing != "" {
+		listingsQuery = listingsQuery.Where("slug = ?", queryData.Listing)
+	}
+	listingsQuery.Find(&listings)
+	if queryData.Listing != "" && len(listings) == 0 {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid listing!"}.Init())
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, 1-days)
+	response := schemas.AnalyticsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Analytics fetched"}.Init(),
+		Data:           models.GetListingAnalytics(db, listings, since),
+	}
+	return c.Status(200).JSON(response)
+}
@@ -0,0 +1,268 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// Sets the parent of a category from a slug. An empty slug makes it a root category
+func setCategoryParent(db *gorm.DB, category *models.Category, parentSlug string) *utils.ErrorResponse {
+	if parentSlug == "" {
+		category.ParentId = nil
+		return nil
+	}
+	parent := models.Category{Slug: &parentSlug}
+	db.Take(&parent, parent)
+	if parent.ID == uuid.Nil {
+		return invalidEntry("parent", "Invalid category!")
+	}
+	if category.ID != uuid.Nil {
+		// Prevent cycles in the tree
+		for _, descendantId := range models.CategoryDescendantIds(db, category.ID) {
+			if descendantId == parent.ID {
+				return invalidEntry("parent", "A category can't be moved under itself or its subcategories!")
+			}
+		}
+	}
+	category.ParentId = &parent.ID
+	return nil
+}
+
+// @Summary Retrieve the category tree
+// @Description This endpoint retrieves the root categories with their subcategories nested under them.
+// @Tags Listings
+// @Success 200 {object} schemas.CategoriesResponseSchema
+// @Router /listings/categories/tree [get]
+func GetCategoryTree(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	response := schemas.CategoriesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Category tree fetched"}.Init(),
+		Data:           models.CategoryTree(db),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Create a category
+// @Description This endpoint creates a category. Staff only.
+// @Tags Listings
+// @Param category body schemas.CreateCategorySchema true "Create Category"
+// @Success 201 {object} schemas.CategoryResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /listings/categories [post]
+// @Security BearerAuth
+func CreateCategory(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	validator := utils.Validator()
+
+	categoryData := schemas.CreateCategorySchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &categoryData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(categoryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	category := models.Category{Name: categoryData.Name, DisplayOrder: categoryData.DisplayOrder, Icon: categoryData.Icon}
+	if categoryData.Parent != nil {
+		if errData := setCategoryParent(db, &category, *categoryData.Parent); errData != nil {
+			return c.Status(422).JSON(errData)
+		}
+	}
+	db.Create(&category)
+	db.Preload("ParentObj").Take(&category, category.ID)
+
+	response := schemas.CategoryResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Category created successfully"}.Init(),
+		Data:           category.Init(),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Update a category
+// @Description This endpoint updates a category. Changing the name regenerates the slug. Pass an empty parent to make it a root category. Staff only.
+// @Tags Listings
+// @Param slug path string true  "Category Slug"
+// @Param category body schemas.UpdateCategorySchema true "Update Category"
+// @Success 200 {object} schemas.CategoryResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /listings/categories/{slug} [patch]
+// @Security BearerAuth
+func UpdateCategory(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	validator := utils.Validator()
+
+	categorySlug := c.Params("slug")
+	category := models.Category{Slug: &categorySlug}
+	db.Take(&category, category)
+	if category.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid category!"}.Init())
+	}
+
+	categoryData := schemas.UpdateCategorySchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &categoryData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(categoryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	if categoryData.Parent != nil {
+		if errData := setCategoryParent(db, &category, *categoryData.Parent); errData != nil {
+			return c.Status(422).JSON(errData)
+		}
+	}
+	if categoryData.Name != nil {
+		category.Name = *categoryData.Name
+	}
+	if categoryData.DisplayOrder != nil {
+		category.DisplayOrder = *categoryData.DisplayOrder
+	}
+	if categoryData.Icon != nil {
+		category.Icon = categoryData.Icon
+	}
+	db.Save(&category)
+	db.Preload("ParentObj").Take(&category, category.ID)
+
+	response := schemas.CategoryResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Category updated successfully"}.Init(),
+		Data:           category.Init(),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Delete a category
+// @Description This endpoint deletes a category without subcategories. Its listings move to category 'other'. Staff only.
+// @Tags Listings
+// @Param slug path string true  "Category Slug"
+// @Success 200 {object} schemas.ResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /listings/categories/{slug} [delete]
+// @Security BearerAuth
+func DeleteCategory(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	categorySlug := c.Params("slug")
+	category := models.Category{Slug: &categorySlug}
+	db.Take(&category, category)
+	if category.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid category!"}.Init())
+	}
+
+	var childrenCount int64
+	db.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&childrenCount)
+	if childrenCount > 0 {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "Move or delete this category's subcategories first!"}.Init())
+	}
+	db.Delete(&category)
+	return c.Status(200).JSON(schemas.ResponseSchema{Message: "Category deleted successfully"}.Init())
+}
+
+// @Summary Retrieve the attributes of a category
+// @Description This endpoint retrieves the attributes listings in a category can have, including those inherited from its parent categories.
+// @Tags Listings
+// @Param slug path string true  "Category Slug"
+// @Success 200 {object} schemas.CategoryAttributesResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /listings/categories/{slug}/attributes [get]
+func GetCategoryAttributes(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	categorySlug := c.Params("slug")
+	category := models.Category{Slug: &categorySlug}
+	db.Take(&category, category)
+	if category.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid category!"}.Init())
+	}
+
+	response := schemas.CategoryAttributesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Category attributes fetched"}.Init(),
+		Data:           models.CategoryAttributes(db, &category.ID),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Set the attributes of a category
+// @Description This endpoint replaces the attributes defined on a category. Its subcategories inherit them, so names can't repeat those of its parents or subcategories. Existing listings keep their values until they're updated. Staff only.
+// @Tags Listings
+// @Param slug path string true  "Category Slug"
+// @Param attributes body schemas.UpdateCategoryAttributesSchema true "Category Attributes"
+// @Success 200 {object} schemas.CategoryAttributesResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /listings/categories/{slug}/attributes [put]
+// @Security BearerAuth
+func UpdateCategoryAttributes(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	validator := utils.Validator()
+
+	categorySlug := c.Params("slug")
+	category := models.Category{Slug: &categorySlug}
+	db.Take(&category, category)
+	if category.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid category!"}.Init())
+	}
+
+	attributesData := schemas.UpdateCategoryAttributesSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &attributesData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(attributesData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	// Names must be unique across the category, its parents and its subcategories since values are keyed by them
+	names := map[string]bool{}
+	for _, attribute := range models.CategoryAttributes(db, category.ParentId) {
+		names[attribute.Name] = true
+	}
+	for _, attribute := range models.SubcategoryAttributes(db, category.ID) {
+		names[attribute.Name] = true
+	}
+	attributes := []models.CategoryAttribute{}
+	for _, attributeData := range attributesData.Attributes {
+		if names[attributeData.Name] {
+			return c.Status(422).JSON(invalidEntry("attributes", fmt.Sprintf("Duplicate attribute: %s", attributeData.Name)))
+		}
+		names[attributeData.Name] = true
+		attribute := models.CategoryAttribute{
+			Name:         attributeData.Name,
+			Label:        attributeData.Label,
+			Type:         attributeData.Type,
+			Required:     attributeData.Required,
+			DisplayOrder: attributeData.DisplayOrder,
+		}
+		if attribute.Type == models.AttributeEnum {
+			attribute.Options = attributeData.Options
+		}
+		attributes = append(attributes, attribute)
+	}
+	if err := models.SetCategoryAttributes(db, category.ID, attributes); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+
+	response := schemas.CategoryAttributesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Category attributes updated"}.Init(),
+		Data:           models.CategoryAttributes(db, &category.ID),
+	}
+	return c.Status(200).JSON(response)
+}
@@ -0,0 +1,268 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+const defaultMessagesLimit = 50
+
+// Gets a conversation from the 'id' path param. Only its two participants and staff can access it
+func getConversation(c *fiber.Ctx, db *gorm.DB, user *models.User) (*models.Conversation, int, *utils.ErrorResponse) {
+	conversationId, err := uuid.FromString(c.Params("id"))
+	conversation := models.Conversation{}
+	if err == nil {
+		db.Preload("ListingObj").Preload("BuyerObj").Preload("SellerObj").Take(&conversation, conversationId)
+	}
+	if conversation.ID == uuid.Nil {
+		errResp := utils.ErrorResponse{Message: "Invalid conversation!"}.Init()
+		return nil, 404, &errResp
+	}
+	if !conversation.HasParticipant(user.ID) && (user.IsStaff == nil || !*user.IsStaff) {
+		errResp := utils.ErrorResponse{Message: "You're not part of this conversation!"}.Init()
+		return nil, 403, &errResp
+	}
+	return &conversation, 0, nil
+}
+
+// @Summary Retrieve conversations
+// @Description This endpoint retrieves the current user's conversations with buyers and sellers, latest activity first.
+// @Tags Conversations
+// @Success 200 {object} schemas.ConversationsResponseSchema
+// @Router /conversations [get]
+// @Security BearerAuth
+func GetConversations(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	conversations := []models.Conversation{}
+	db.Preload("ListingObj").Preload("BuyerObj").Preload("SellerObj").Where("buyer_id = ? OR seller_id = ?", user.ID, user.ID).Order("last_message_at DESC NULLS LAST, created_at DESC").Find(&conversations)
+	for i := range conversations {
+		conversations[i] = conversations[i].Init(db, user.ID)
+	}
+
+	response := schemas.ConversationsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Conversations fetched"}.Init(),
+		Data:           conversations,
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Retrieve the number of unread messages
+// @Description This endpoint retrieves the number of messages sent to the current user that they haven't read yet.
+// @Tags Conversations
+// @Success 200 {object} schemas.UnreadMessagesResponseSchema
+// @Router /conversations/unread [get]
+// @Security BearerAuth
+func GetUnreadMessagesCount(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	response := schemas.UnreadMessagesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Unread messages count fetched"}.Init(),
+		Data:           schemas.UnreadMessagesResponseDataSchema{UnreadCount: models.UnreadMessagesCount(db, user.ID)},
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Start a conversation
+// @Description This endpoint opens the conversation between the auctioneer of a listing and one of its winners, or returns it if it already exists. Winners only pass the listing, auctioneers also pass the winner as buyer.
+// @Tags Conversations
+// @Param conversation body schemas.StartConversationSchema true "Conversation"
+// @Success 200 {object} schemas.ConversationResponseSchema
+// @Success 201 {object} schemas.ConversationResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /conversations [post]
+// @Security BearerAuth
+func StartConversation(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	conversationData := schemas.StartConversationSchema{}
+	if errCode, errData := DecodeJSONBody(c, &conversationData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := utils.Validator().Validate(conversationData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	listing := models.Listing{Slug: &conversationData.Listing}
+	db.Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
+	}
+
+	buyerId := user.ID
+	if listing.AuctioneerId == user.ID {
+		if conversationData.Buyer == nil {
+			return c.Status(422).JSON(invalidEntry("buyer", "This field is required."))
+		}
+		buyerId = uuid.FromStringOrNil(*conversationData.Buyer)
+		if !models.IsListingWinner(db, listing.ID, buyerId) {
+			return c.Status(422).JSON(invalidEntry("buyer", "This user didn't win the listing!"))
+		}
+	} else if !models.IsListingWinner(db, listing.ID, user.ID) {
+		return c.Status(403).JSON(utils.ErrorResponse{Message: "Only the winners of a listing can message its auctioneer!"}.Init())
+	}
+
+	statusCode := 200
+	conversation := models.Conversation{ListingId: listing.ID, BuyerId: buyerId}
+	if db.Take(&conversation, conversation).Error == gorm.ErrRecordNotFound {
+		conversation.SellerId = listing.AuctioneerId
+		db.Create(&conversation)
+		statusCode = 201
+	}
+	db.Preload("ListingObj").Preload("BuyerObj").Preload("SellerObj").Take(&conversation, conversation.ID)
+
+	response := schemas.ConversationResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Conversation fetched"}.Init(),
+		Data:           conversation.Init(db, user.ID),
+	}
+	return c.Status(statusCode).JSON(response)
+}
+
+// @Summary Retrieve the messages of a conversation
+// @Description This endpoint retrieves the messages of a conversation a page at a time, latest first, and marks the ones sent to the current user as read. Staff can read any conversation to handle disputes, without marking anything as read.
+// @Tags Conversations
+// @Param id path string true  "Conversation ID"
+// @Param page query int false  "Page Number"
+// @Param limit query int false  "Page Size (max 100)"
+// @Success 200 {object} schemas.MessagesResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /conversations/{id}/messages [get]
+// @Security BearerAuth
+func GetConversationMessages(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	conversation, errCode, errData := getConversation(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+
+	queryData := schemas.MessagesQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid query params!"}.Init())
+	}
+	if err := utils.Validator().Validate(queryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	limit := queryData.Limit
+	if limit == 0 {
+		limit = defaultMessagesLimit
+	}
+	page := queryData.Page
+	if page == 0 {
+		page = 1
+	}
+
+	if conversation.HasParticipant(user.ID) {
+		models.MarkMessagesRead(db, conversation.ID, user.ID)
+	}
+	var total int64
+	db.Model(&models.Message{}).Where("conversation_id = ?", conversation.ID).Count(&total)
+	messages := []models.Message{}
+	db.Preload("SenderObj").Preload("Attachment").Where("conversation_id = ?", conversation.ID).Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&messages)
+	for i := range messages {
+		messages[i] = messages[i].Init(db)
+	}
+
+	response := schemas.MessagesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Messages fetched"}.Init(),
+		Data:           schemas.MessagesResponseDataSchema{Conversation: conversation.Init(db, user.ID), Messages: messages},
+		Meta:           schemas.SearchMetaSchema{Total: total, Page: page, Limit: limit},
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Send a message
+// @Description This endpoint sends a message in a conversation. Pass file_type to attach an image, then use the returned file_upload_data to upload it.
+// @Tags Conversations
+// @Param id path string true  "Conversation ID"
+// @Param message body schemas.SendMessageSchema true "Message"
+// @Success 201 {object} schemas.MessageResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /conversations/{id}/messages [post]
+// @Security BearerAuth
+func SendMessage(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	conversation, errCode, errData := getConversation(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if !conversation.HasParticipant(user.ID) {
+		return c.Status(403).JSON(utils.ErrorResponse{Message: "You're not part of this conversation!"}.Init())
+	}
+
+	messageData := schemas.SendMessageSchema{}
+	if errCode, errData := DecodeJSONBody(c, &messageData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := utils.Validator().Validate(messageData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	message := models.Message{ConversationId: conversation.ID, SenderId: user.ID, SenderObj: *user, Body: messageData.Body}
+	if messageData.FileType != nil {
+		attachment := models.NewUpload(user.ID, models.FolderMessages, *messageData.FileType)
+		db.Create(&attachment)
+		message.AttachmentId = &attachment.ID
+		message.Attachment = &attachment
+	}
+	db.Omit("SenderObj", "Attachment").Create(&message)
+	db.Model(conversation).Update("last_message_at", time.Now().UTC())
+
+	response := schemas.MessageResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Message sent successfully"}.Init(),
+		Data:           schemas.MessageResponseDataSchema{Message: message.Init(db), FileUploadData: message.GetAttachmentUploadData()},
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Retrieve the conversations of a listing
+// @Description This endpoint retrieves every conversation about a listing, to handle disputes between its auctioneer and winners. Staff only.
+// @Tags Moderation
+// @Param slug path string true  "Listing Slug"
+// @Success 200 {object} schemas.ConversationsResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /moderation/listings/{slug}/conversations [get]
+// @Security BearerAuth
+func GetListingConversations(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	listingSlug := c.Params("slug")
+
+	listing := models.Listing{Slug: &listingSlug}
+	db.Unscoped().Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid listing!"}.Init())
+	}
+
+	conversations := []models.Conversation{}
+	db.Preload("ListingObj").Preload("BuyerObj").Preload("SellerObj").Where("listing_id = ?", listing.ID).Order("created_at ASC").Find(&conversations)
+	for i := range conversations {
+		conversations[i] = conversations[i].Init(db, user.ID)
+	}
+
+	response := schemas.ConversationsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing conversations fetched"}.Init(),
+		Data:           conversations,
+	}
+	return c.Status(200).JSON(response)
+}
@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+func getExchangeRates(db *gorm.DB) schemas.ExchangeRatesResponseDataSchema {
+	rates := []models.ExchangeRate{}
+	db.Order("currency ASC").Find(&rates)
+	return schemas.ExchangeRatesResponseDataSchema{BaseCurrency: models.BaseCurrency(), Rates: rates}
+}
+
+// @Summary Retrieve exchange rates
+// @Description This endpoint retrieves the supported currencies and their rates against the base currency.
+// @Tags Currencies
+// @Success 200 {object} schemas.ExchangeRatesResponseSchema
+// @Router /currencies [get]
+func GetExchangeRates(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	response := schemas.ExchangeRatesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Exchange rates fetched"}.Init(),
+		Data:           getExchangeRates(db),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Update exchange rates
+// @Description This endpoint creates or updates exchange rates (units of a currency per unit of the base currency). Staff only.
+// @Tags Currencies
+// @Param rates body schemas.UpdateExchangeRatesSchema true "Exchange Rates"
+// @Success 200 {object} schemas.ExchangeRatesResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /currencies [put]
+// @Security BearerAuth
+func UpdateExchangeRates(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	validator := utils.Validator()
+
+	ratesData := schemas.UpdateExchangeRatesSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &ratesData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(ratesData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	for currency, rate := range ratesData.Rates {
+		if currency == models.BaseCurrency() || !rate.IsPositive() {
+			data := map[string]string{
+				"rates": fmt.Sprintf("Invalid rate for %s", currency),
+			}
+			return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
+		}
+	}
+
+	if err := models.SetExchangeRates(db, ratesData.Rates); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+
+	response := schemas.ExchangeRatesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Exchange rates updated"}.Init(),
+		Data:           getExchangeRates(db),
+	}
+	return c.Status(200).JSON(response)
+}
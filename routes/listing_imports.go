@@ -0,0 +1,257 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+const maxImportedListings = 100
+
+// Import modes
+const (
+	ImportAllOrNothing			= "all_or_nothing"
+	ImportPartial				= "partial"
+)
+
+// Fills a listing schema field from a CSV cell, converting it to the field's type
+func setCSVField(field reflect.Value, value string) string {
+	fieldType := field.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	var parsed interface{}
+	switch fieldType.Kind() {
+	case reflect.Float64:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "Must be a number!"
+		}
+		parsed = number
+	case reflect.Int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return "Must be a whole number!"
+		}
+		parsed = number
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return "Must be true or false!"
+		}
+		parsed = boolean
+	default:
+		parsed = value
+	}
+	parsedValue := reflect.ValueOf(parsed).Convert(fieldType)
+	if field.Kind() == reflect.Ptr {
+		pointer := reflect.New(fieldType)
+		pointer.Elem().Set(parsedValue)
+		parsedValue = pointer
+	}
+	field.Set(parsedValue)
+	return ""
+}
+
+// Reads listings from a CSV file whose header names the fields like the JSON body does.
+// Attribute columns are named 'attr.<name>'. Empty cells are left out
+func listingsFromCSV(db *gorm.DB, content []byte) ([]schemas.CreateListingSchema, map[int]map[string]string, *utils.ErrorResponse) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil || len(records) < 1 {
+		errResp := utils.ErrorResponse{Message: "Invalid CSV file!"}.Init()
+		return nil, nil, &errResp
+	}
+
+	// Match the columns with the schema fields
+	fieldsByColumn := map[string]int{}
+	schemaType := reflect.TypeOf(schemas.CreateListingSchema{})
+	for i := 0; i < schemaType.NumField(); i++ {
+		fieldsByColumn[strings.Split(schemaType.Field(i).Tag.Get("json"), ",")[0]] = i
+	}
+	header := records[0]
+	for _, column := range header {
+		if _, ok := fieldsByColumn[column]; column == "attributes" || (!ok && !strings.HasPrefix(column, "attr.")) {
+			return nil, nil, invalidEntry(column, "Unknown column!")
+		}
+	}
+
+	listingsData := []schemas.CreateListingSchema{}
+	rowErrors := map[int]map[string]string{}
+	for i, record := range records[1:] {
+		listingData := schemas.CreateListingSchema{}
+		value := reflect.ValueOf(&listingData).Elem()
+		attributeValues := map[string]string{}
+		for j, cell := range record {
+			if cell == "" {
+				continue
+			}
+			if strings.HasPrefix(header[j], "attr.") {
+				attributeValues[strings.TrimPrefix(header[j], "attr.")] = cell
+			} else if errMsg := setCSVField(value.Field(fieldsByColumn[header[j]]), cell); errMsg != "" {
+				if rowErrors[i] == nil {
+					rowErrors[i] = map[string]string{}
+				}
+				rowErrors[i][header[j]] = errMsg
+			}
+		}
+
+		// Attribute types depend on the category
+		if len(attributeValues) > 0 {
+			var categoryId *uuid.UUID
+			category := models.Category{Slug: &listingData.Category}
+			if listingData.Category != "" && db.Take(&category, category).Error == nil {
+				categoryId = &category.ID
+			}
+			listingData.Attributes = models.ParseAttributeValues(models.CategoryAttributes(db, categoryId), attributeValues)
+		}
+		listingsData = append(listingsData, listingData)
+	}
+	return listingsData, rowErrors, nil
+}
+
+// @Summary Import listings
+// @Description This endpoint creates many listings at once from a JSON body ({"listings": [...]}, each entry like the create listing body) or a CSV file sent with the text/csv content type. CSV columns are named like the JSON fields, with attributes in 'attr.<name>' columns. Every row is validated and its errors are returned with its row number (starting at 1). With mode=all_or_nothing (the default) nothing is created if any row is invalid, while mode=partial creates the valid rows. Note: Use the returned file_upload_data of each listing to upload its image
+// @Tags Auctioneer
+// @Param mode query string false  "Import Mode (all_or_nothing or partial)"
+// @Param listings body schemas.ImportListingsSchema true "Listings"
+// @Success 201 {object} schemas.ImportListingsResponseSchema
+// @Failure 422 {object} schemas.ImportListingsResponseSchema
+// @Router /auctioneer/listings/import [post]
+// @Security BearerAuth
+func ImportListings(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	queryData := schemas.ImportListingsQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid query params!"}.Init())
+	}
+	if err := validator.Validate(queryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	mode := queryData.Mode
+	if mode == "" {
+		mode = ImportAllOrNothing
+	}
+
+	listingsData := []schemas.CreateListingSchema{}
+	rowErrors := map[int]map[string]string{}
+	if strings.HasPrefix(c.Get("Content-Type"), "text/csv") {
+		var errData *utils.ErrorResponse
+		listingsData, rowErrors, errData = listingsFromCSV(db, c.Body())
+		if errData != nil {
+			return c.Status(422).JSON(errData)
+		}
+	} else {
+		importData := schemas.ImportListingsSchema{}
+		if errCode, errData := DecodeJSONBody(c, &importData); errData != nil {
+			return c.Status(errCode).JSON(errData)
+		}
+		listingsData = importData.Listings
+	}
+	if len(listingsData) == 0 {
+		return c.Status(422).JSON(invalidEntry("listings", "This field is required."))
+	}
+	if len(listingsData) > maxImportedListings {
+		return c.Status(422).JSON(invalidEntry("listings", fmt.Sprintf("A maximum of %d listings can be imported at once!", maxImportedListings)))
+	}
+
+	// Validate every row
+	type importRow struct {
+		row				int
+		listing			*models.Listing
+		fileType		string
+	}
+	validRows := []importRow{}
+	importErrors := []schemas.ListingImportErrorSchema{}
+	for i, listingData := range listingsData {
+		if rowErrors[i] != nil {
+			data := rowErrors[i]
+			importErrors = append(importErrors, schemas.ListingImportErrorSchema{Row: i + 1, ErrorResponse: utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init()})
+			continue
+		}
+		if err := validator.Validate(listingData); err != nil {
+			importErrors = append(importErrors, schemas.ListingImportErrorSchema{Row: i + 1, ErrorResponse: *err})
+			continue
+		}
+		listing, errData := newListingFromSchema(db, user, listingData)
+		if errData != nil {
+			importErrors = append(importErrors, schemas.ListingImportErrorSchema{Row: i + 1, ErrorResponse: utils.ErrorResponse{Message: "Invalid Entry", Data: &errData}.Init()})
+			continue
+		}
+		validRows = append(validRows, importRow{row: i + 1, listing: listing, fileType: listingData.FileType})
+	}
+
+	savedRows := []importRow{}
+	rowFailed := false
+	if len(validRows) > 0 && (mode == ImportPartial || len(importErrors) == 0) {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, validRow := range validRows {
+				savePoint := fmt.Sprintf("row_%d", validRow.row)
+				tx.SavePoint(savePoint)
+				if err := saveNewListing(tx, validRow.listing, validRow.fileType); err != nil {
+					importErrors = append(importErrors, schemas.ListingImportErrorSchema{Row: validRow.row, ErrorResponse: utils.ErrorResponse{Message: "This listing couldn't be saved!"}.Init()})
+					if mode == ImportAllOrNothing {
+						rowFailed = true
+						return err
+					}
+					tx.RollbackTo(savePoint)
+					continue
+				}
+				savedRows = append(savedRows, validRow)
+			}
+			return nil
+		})
+		if rowFailed {
+			// Nothing was saved, so the failed row is reported like an invalid one
+			savedRows = []importRow{}
+		} else if err != nil {
+			return c.Status(500).JSON(utils.ErrorResponse{Message: "The listings couldn't be imported!"}.Init())
+		}
+	}
+	// Rows that failed to save are reported after the invalid ones, so the errors are put back in row order
+	sort.SliceStable(importErrors, func(i, j int) bool {
+		return importErrors[i].Row < importErrors[j].Row
+	})
+
+	created := []schemas.ImportedListingSchema{}
+	for _, savedRow := range savedRows {
+		listing := savedRow.listing
+		db.Preload(clause.Associations).Take(listing, listing.ID)
+		created = append(created, schemas.ImportedListingSchema{
+			Row: savedRow.row,
+			CreateListingResponseDataSchema: schemas.CreateListingResponseDataSchema{
+				Listing:        listing.Init(db),
+				FileUploadData: listing.GetImageUploadData(db),
+			},
+		})
+	}
+
+	responseData := schemas.ImportListingsResponseDataSchema{Created: created, Errors: importErrors}
+	if len(created) == 0 {
+		response := schemas.ImportListingsResponseSchema{
+			ResponseSchema: schemas.ResponseSchema{Status: "failure", Message: "No listings were imported"}.Init(),
+			Data:           responseData,
+		}
+		return c.Status(422).JSON(response)
+	}
+	response := schemas.ImportListingsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: fmt.Sprintf("%d listings imported successfully", len(created))}.Init(),
+		Data:           responseData,
+	}
+	return c.Status(201).JSON(response)
+}
@@ -1,77 +1,216 @@
 package routes
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/kayprogrammer/bidout-auction-v7/analytics"
+	"github.com/kayprogrammer/bidout-auction-v7/auctions"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/payments"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
 	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/senders"
 	"github.com/kayprogrammer/bidout-auction-v7/utils"
 	uuid "github.com/satori/go.uuid"
+	"github.com/valyala/fasthttp"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 // @Summary Retrieve all listings
-// @Description This endpoint retrieves all listings.
+// @Description This endpoint retrieves listings a page at a time. Pass the returned meta.next_cursor as 'cursor' to get the next page. Filter by category attributes with 'attr.<name>=value', and by number attributes with 'attr.<name>.min' and 'attr.<name>.max' (e.g attr.year.min=2015).
 // @Tags Listings
-// @Param quantity query int false  "Listings Quantity"
-// @Success 200 {object} schemas.ListingsResponseSchema
+// @Param cursor query string false  "Page Cursor"
+// @Param limit query int false  "Page Size (max 100)"
+// @Param quantity query int false  "Listings Quantity (same as limit)"
+// @Param category query string false  "Category Slug (use 'other' for uncategorized listings)"
+// @Param min_price query number false  "Minimum Price"
+// @Param max_price query number false  "Maximum Price"
+// @Param status query string false  "Auction Status (upcoming, live or ended)"
+// @Param auctioneer query string false  "Auctioneer ID"
+// @Param sort query string false  "Sort (newest, ending_soon, price_asc, price_desc or most_bids)"
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
+// @Success 200 {object} schemas.PaginatedListingsResponseSchema
+// @Failure 422 {object} utils.ErrorResponse
 // @Router /listings [get]
 // @Security BearerAuth
 // @Security GuestUserAuth
 func GetListings(c *fiber.Ctx) error {
 	db := c.Locals("db").(*gorm.DB)
 	client := GetClient(c)
-	listings := []models.Listing{}
-	quantity := c.QueryInt("quantity")
+	currency := DisplayCurrency(c)
+
+	query, errData := ListingQueryFromRequest(c, db)
+	if errData != nil {
+		return c.Status(422).JSON(errData)
+	}
+	query.States = models.PublicListingStates()
+	query.Archived = false
+
 	// Get listings
-	db.Preload(clause.Associations).Order("created_at DESC").Find(&listings)
+	listings, meta, errData := PaginateListings(db, query)
+	if errData != nil {
+		return c.Status(422).JSON(errData)
+	}
 
 	// Initialize each listing object in the slice
 	for i := range listings {
-		listings[i] = listings[i].Init(db)
-		if client != nil {
-			watchlist := models.Watchlist{ListingId: listings[i].ID}
-			db.Where(db.Where(models.Watchlist{UserId: &client.ID}).Or(models.Watchlist{GuestUserId: &client.ID})).Take(&watchlist, watchlist)
-			if watchlist.ID != uuid.Nil {
-				listings[i].Watchlist = true
-			}
-		}
+		listings[i] = listings[i].Init(db).InCurrency(db, currency)
 	}
-	if quantity > 0 {
-		listings = listings[:quantity]
-	}
-	response := schemas.ListingsResponseSchema{
+	SetWatchlistFlags(db, client, listings)
+	analytics.RecordImpressions(listings)
+	response := schemas.PaginatedListingsResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Listings fetched"}.Init(),
 		Data:           listings,
+		Meta:           meta,
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Search listings
+// @Description This endpoint searches listings by name, description and category name. Words match as prefixes, close misspellings of the name still match, and matched words are wrapped in <mark> tags in the highlights.
+// @Tags Listings
+// @Param q query string true  "Search Terms"
+// @Param status query string false  "Auction Status (upcoming, live or ended)"
+// @Param limit query int false  "Page Size (max 50)"
+// @Param page query int false  "Page"
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
+// @Success 200 {object} schemas.ListingSearchResponseSchema
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /listings/search [get]
+// @Security BearerAuth
+// @Security GuestUserAuth
+func SearchListings(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	client := GetClient(c)
+	currency := DisplayCurrency(c)
+
+	queryData := schemas.SearchListingsQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid query params!"}.Init())
+	}
+	if err := utils.Validator().Validate(queryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	limit := queryData.Limit
+	if limit == 0 {
+		limit = defaultListingsLimit
+	}
+	page := queryData.Page
+	if page == 0 {
+		page = 1
+	}
+
+	// Search, then load the matched listings in rank order
+	hits, total := models.SearchListings(db, queryData.Q, queryData.Status, limit, (page-1)*limit)
+	listingIds := []uuid.UUID{}
+	for _, hit := range hits {
+		listingIds = append(listingIds, hit.ID)
+	}
+	listingsById := map[uuid.UUID]models.Listing{}
+	if len(listingIds) > 0 {
+		matchedListings := []models.Listing{}
+		db.Scopes(models.PreloadListingSummaries).Where("id IN ?", listingIds).Find(&matchedListings)
+		models.LoadBidSummaries(db, matchedListings)
+		for _, listing := range matchedListings {
+			listingsById[listing.ID] = listing
+		}
+	}
+	listings := []models.Listing{}
+	matchedHits := []models.ListingSearchHit{}
+	for _, hit := range hits {
+		if listing, ok := listingsById[hit.ID]; ok {
+			listings = append(listings, listing.Init(db).InCurrency(db, currency))
+			matchedHits = append(matchedHits, hit)
+		}
+	}
+	SetWatchlistFlags(db, client, listings)
+	analytics.RecordImpressions(listings)
+
+	results := []schemas.ListingSearchResultSchema{}
+	for i, listing := range listings {
+		hit := matchedHits[i]
+		results = append(results, schemas.ListingSearchResultSchema{
+			Listing:    listing,
+			Rank:       hit.Rank,
+			Highlights: schemas.ListingSearchHighlightsSchema{Name: hit.NameHighlight, Desc: hit.DescHighlight},
+		})
+	}
+
+	response := schemas.ListingSearchResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listings search results fetched"}.Init(),
+		Data:           results,
+		Meta:           schemas.SearchMetaSchema{Total: total, Page: page, Limit: limit},
 	}
 	return c.Status(200).JSON(response)
 }
 
 // @Summary Retrieve listing's detail
-// @Description This endpoint retrieves detail of a listing.
+// @Description This endpoint retrieves detail of a listing with a page of its questions (see questions_page and questions_limit). Old slugs of renamed listings redirect to the current one with a 301, which has the canonical_slug in its body.
 // @Tags Listings
 // @Param slug path string true  "Listing Slug"
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
+// @Param questions_page query int false  "Questions Page Number"
+// @Param questions_limit query int false  "Questions Page Size (max 50)"
 // @Success 200 {object} schemas.ListingDetailResponseSchema
+// @Success 301 {object} schemas.SlugMovedResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
 // @Router /listings/detail/{slug} [get]
+// @Security BearerAuth
+// @Security GuestUserAuth
 func GetListing(c *fiber.Ctx) error {
 	db := c.Locals("db").(*gorm.DB)
 	slug := c.Params("slug")
 	listing := models.Listing{Slug: &slug}
 
 	// Get listing
-	db.Preload(clause.Associations).Take(&listing, listing)
+	db.Preload(clause.Associations).Scopes(models.PubliclyVisible).Take(&listing, listing)
 	if listing.ID == uuid.Nil {
+		if canonicalSlug := models.CanonicalListingSlug(db, slug); canonicalSlug != nil {
+			return SlugMoved(c, slug, *canonicalSlug)
+		}
 		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
 	}
-	listing = listing.Init(db)
+	if client := GetClient(c); client == nil || client.ID != listing.AuctioneerId {
+		analytics.RecordView(listing.ID, ViewerKey(c))
+	}
+	listing = listing.Init(db).WithImages(db).InCurrency(db, DisplayCurrency(c))
+
+	queryData := schemas.ListingDetailQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid query params!"}.Init())
+	}
+	if err := utils.Validator().Validate(queryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	questionsLimit := queryData.QuestionsLimit
+	if questionsLimit == 0 {
+		questionsLimit = defaultQuestionsLimit
+	}
+	questionsPage := queryData.QuestionsPage
+	if questionsPage == 0 {
+		questionsPage = 1
+	}
+	questions, questionsTotal := models.FindListingQuestions(db, map[string]interface{}{"listing_id": listing.ID, "hidden": false}, questionsPage, questionsLimit)
+
 	relatedListings := []models.Listing{}
-	db.Preload(clause.Associations).Order("created_at DESC").Not(models.BaseModel{ID: listing.ID}).Limit(3).Find(&relatedListings, models.Listing{CategoryId: listing.CategoryId})
+	db.Preload(clause.Associations).Scopes(models.PubliclyVisible).Order("created_at DESC").Not(models.BaseModel{ID: listing.ID}).Limit(3).Find(&relatedListings, models.Listing{CategoryId: listing.CategoryId})
 
 	response := schemas.ListingDetailResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Listing details fetched"}.Init(),
 		Data: schemas.ListingDetailResponseDataSchema{
 			Listing:         listing,
 			RelatedListings: relatedListings,
+			Questions: schemas.ListingQuestionsSchema{
+				Items: questions,
+				Meta:  schemas.SearchMetaSchema{Total: questionsTotal, Page: questionsPage, Limit: questionsLimit},
+			},
 		},
 	}
 	return c.Status(200).JSON(response)
@@ -80,6 +219,7 @@ func GetListing(c *fiber.Ctx) error {
 // @Summary Retrieve all listings by users watchlist
 // @Description This endpoint retrieves all watchlist listings.
 // @Tags Listings
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
 // @Success 200 {object} schemas.ListingsResponseSchema
 // @Router /listings/watchlist [get]
 // @Security BearerAuth
@@ -87,6 +227,7 @@ func GetListing(c *fiber.Ctx) error {
 func GetWatchlistListings(c *fiber.Ctx) error {
 	db := c.Locals("db").(*gorm.DB)
 	client := GetClient(c)
+	currency := DisplayCurrency(c)
 	watchlists := []models.Watchlist{}
 	listings := []models.Listing{}
 
@@ -96,8 +237,11 @@ func GetWatchlistListings(c *fiber.Ctx) error {
 	}
 	for i := range watchlists {
 		listing := watchlists[i].Listing
+		if !listing.IsPublic() {
+			continue
+		}
 		listing.Watchlist = true
-		listings = append(listings, listing.Init(db))
+		listings = append(listings, listing.Init(db).InCurrency(db, currency))
 	}
 
 	response := schemas.ListingsResponseSchema{
@@ -108,7 +252,7 @@ func GetWatchlistListings(c *fiber.Ctx) error {
 }
 
 // @Summary Add or Remove listing from a users watchlist
-// @Description This endpoint adds or removes a listing from a user's watchlist, authenticated or not.... As a guest, ensure to store guestuser_id in localstorage and keep passing it to header 'guestuserid' in subsequent requests
+// @Description This endpoint adds or removes a listing from a user's watchlist, authenticated or not.... As a guest, ensure to store guestuser_id in localstorage and keep passing it to header 'guestuserid' in subsequent requests. The old slug of a renamed listing works too, and canonical_slug in the response is its current slug.
 // @Tags Listings
 // @Param listing_slug body schemas.AddOrRemoveWatchlistSchema true "Add/Remove Watchlist"
 // @Success 201 {object} schemas.AddOrRemoveWatchlistResponseSchema
@@ -134,7 +278,14 @@ func AddOrRemoveWatchlistListing(c *fiber.Ctx) error {
 
 	// Get listing
 	listing := models.Listing{Slug: &addRemoveWatchlistData.Slug}
-	db.Preload(clause.Associations).Take(&listing, listing)
+	db.Preload(clause.Associations).Scopes(models.PubliclyVisible).Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		// Deep links may still have the slug of a renamed listing
+		if canonicalSlug := models.CanonicalListingSlug(db, addRemoveWatchlistData.Slug); canonicalSlug != nil {
+			listing = models.Listing{Slug: canonicalSlug}
+			db.Preload(clause.Associations).Scopes(models.PubliclyVisible).Take(&listing, listing)
+		}
+	}
 	if listing.ID == uuid.Nil {
 		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
 	}
@@ -174,7 +325,8 @@ func AddOrRemoveWatchlistListing(c *fiber.Ctx) error {
 	response := schemas.AddOrRemoveWatchlistResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: respMessage}.Init(),
 		Data: schemas.AddOrRemoveWatchlistResponseDataSchema{
-			GuestUserId: guestUserId,
+			GuestUserId:   guestUserId,
+			CanonicalSlug: *listing.Slug,
 		},
 	}
 	return c.Status(statusCode).JSON(response)
@@ -190,7 +342,10 @@ func GetCategories(c *fiber.Ctx) error {
 
 	// Get categories
 	categories := []models.Category{}
-	db.Find(&categories)
+	db.Preload("ParentObj").Scopes(models.OrderCategories).Find(&categories)
+	for i := range categories {
+		categories[i] = categories[i].Init()
+	}
 
 	response := schemas.CategoriesResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Categories fetched"}.Init(),
@@ -201,45 +356,47 @@ func GetCategories(c *fiber.Ctx) error {
 
 
 // @Summary Retrieve all listings by category
-// @Description This endpoint retrieves all listings in a particular category. Use slug 'other' for category other
+// @Description This endpoint retrieves all listings in a particular category, including those in its subcategories. Use slug 'other' for category other. Old slugs of renamed categories redirect to the current one with a 301, which has the canonical_slug in its body.
 // @Tags Listings
 // @Param slug path string true  "Category Slug"
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
 // @Success 200 {object} schemas.ListingsResponseSchema
+// @Success 301 {object} schemas.SlugMovedResponseSchema
 // @Failure 404 {object} utils.ErrorResponse
 // @Router /listings/categories/{slug} [get]
 func GetCategoryListings(c *fiber.Ctx) error {
 	db := c.Locals("db").(*gorm.DB)
 	client := GetClient(c)
+	currency := DisplayCurrency(c)
 	categorySlug := c.Params("slug")
 	
 	// Get Category
-	var categoryId *uuid.UUID
+	listingsQuery := db.Scopes(models.PreloadListingSummaries, models.PubliclyVisible).Order("created_at DESC")
 	if categorySlug == "other" {
-		categoryId = nil
+		listingsQuery = listingsQuery.Where("category_id IS NULL")
 	} else {
 		category := models.Category{Slug: &categorySlug}
 		db.First(&category, category)
 		if category.ID == uuid.Nil {
+			if canonicalSlug := models.CanonicalCategorySlug(db, categorySlug); canonicalSlug != nil {
+				return SlugMoved(c, categorySlug, *canonicalSlug)
+			}
 			return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid category!"}.Init())
 		}
-		categoryId = &category.ID
+		listingsQuery = listingsQuery.Where("category_id IN ?", models.CategoryDescendantIds(db, category.ID))
 	}
 	
 	// Get listings
 	listings := []models.Listing{}
-	db.Preload(clause.Associations).Order("created_at DESC").Find(&listings, models.Listing{CategoryId: categoryId})
+	listingsQuery.Find(&listings)
+	models.LoadBidSummaries(db, listings)
 
 	// Initialize each listing object in the slice
 	for i := range listings {
-		listings[i] = listings[i].Init(db)
-		if client != nil {
-			watchlist := models.Watchlist{ListingId: listings[i].ID}
-			db.Where(db.Where(models.Watchlist{UserId: &client.ID}).Or(models.Watchlist{GuestUserId: &client.ID})).Take(&watchlist, watchlist)
-			if watchlist.ID != uuid.Nil {
-				listings[i].Watchlist = true
-			}
-		}
+		listings[i] = listings[i].Init(db).InCurrency(db, currency)
 	}
+	SetWatchlistFlags(db, client, listings)
+	analytics.RecordImpressions(listings)
 	response := schemas.ListingsResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Category Listings fetched"}.Init(),
 		Data:           listings,
@@ -251,17 +408,19 @@ func GetCategoryListings(c *fiber.Ctx) error {
 // @Description This endpoint retrieves at most 3 bids from a particular listing.
 // @Tags Listings
 // @Param slug path string true  "Listing Slug"
+// @Param currency query string false  "Display Currency (ISO 4217). The Accept-Currency header works too"
 // @Success 200 {object} schemas.BidsResponseSchema
 // @Failure 404 {object} utils.ErrorResponse
 // @Router /listings/detail/{slug}/bids [get]
 func GetListingBids(c *fiber.Ctx) error {
 	db := c.Locals("db").(*gorm.DB)
 	listingSlug := c.Params("slug")
+	currency := DisplayCurrency(c)
 
 	listing := models.Listing{Slug: &listingSlug}
 	db.Preload("Bids", func(db *gorm.DB) *gorm.DB {
 		return db.Order("updated_at DESC").Limit(3) // Order by updated
-	}).Take(&listing, listing)
+	}).Scopes(models.PubliclyVisible).Take(&listing, listing)
 	if listing.ID == uuid.Nil {
 		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid listing!"}.Init())
 	}
@@ -269,7 +428,10 @@ func GetListingBids(c *fiber.Ctx) error {
 	// Get Bids
 	bids := listing.Bids
 	for i := range bids {
-		bids[i] = bids[i].Init(db)
+		bids[i] = bids[i].Init(db).InCurrency(db, listing.Currency, currency)
+		if listing.IsSealed() && !listing.IsClosed() {
+			bids[i] = bids[i].Seal()
+		}
 	}
 
 	response := schemas.BidsResponseSchema{
@@ -279,12 +441,37 @@ func GetListingBids(c *fiber.Ctx) error {
 	return c.Status(200).JSON(response)
 }
 
+// @Summary Retrieve a listing's revision history
+// @Description This endpoint retrieves the edits made to the name and description of a listing since it went live, latest first.
+// @Tags Listings
+// @Param slug path string true  "Listing Slug"
+// @Success 200 {object} schemas.ListingRevisionsResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /listings/detail/{slug}/revisions [get]
+func GetListingRevisions(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	listingSlug := c.Params("slug")
+
+	listing := models.Listing{Slug: &listingSlug}
+	db.Scopes(models.PubliclyVisible).Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid listing!"}.Init())
+	}
+
+	response := schemas.ListingRevisionsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing Revisions fetched"}.Init(),
+		Data:           schemas.ListingRevisionsResponseDataSchema{Listing: listing.Name, Revisions: models.ListingRevisions(db, listing.ID)},
+	}
+	return c.Status(200).JSON(response)
+}
+
 // @Summary Add a bid to a listing
-// @Description This endpoint adds a bid to a particular listing.
+// @Description This endpoint adds a bid to a particular listing. For multi-quantity lots, the amount is per unit and quantity is the number of units wanted. When the listing requires a deposit, the bid total is held from the bidder's wallet until they're outbid.
 // @Tags Listings
 // @Param slug path string true  "Listing Slug"
 // @Param amount body schemas.CreateBidSchema true "Create Bid"
 // @Success 201 {object} schemas.BidResponseSchema
+// @Failure 402 {object} utils.ErrorResponse
 // @Failure 422 {object} utils.ErrorResponse
 // @Failure 404 {object} utils.ErrorResponse
 // @Router /listings/detail/{slug}/bids [post]
@@ -296,13 +483,10 @@ func CreateBid(c *fiber.Ctx) error {
 
 	// Get Listing
 	listing := models.Listing{Slug: &listingSlug}
-	db.Preload("Bids", func(db *gorm.DB) *gorm.DB {
-		return db.Order("updated_at DESC").Limit(1) // Order by updated (Latest bid is surely the highest bid)
-	}).Find(&listing, listing)
+	db.Scopes(models.PubliclyVisible).Take(&listing, listing)
 	if listing.ID == uuid.Nil {
 		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
 	}
-	highestBid := listing.GetHighestBid()
 
 	validator := utils.Validator()
 	createBidData := schemas.CreateBidSchema{}
@@ -315,30 +499,228 @@ func CreateBid(c *fiber.Ctx) error {
 		return c.Status(422).JSON(err)
 	}
 
+	if createBidData.Currency != nil && *createBidData.Currency != listing.Currency {
+		data := map[string]string{
+			"currency": fmt.Sprintf("Bids must be placed in the listing's currency (%s)!", listing.Currency),
+		}
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init())
+	}
+
 	amount := utils.DecimalParser(createBidData.Amount)
-	if user.ID == listing.AuctioneerId {
-		return c.Status(403).JSON(utils.ErrorResponse{Message: "You cannot bid your own product!"}.Init())
-	} else if !listing.Active {
-		return c.Status(410).JSON(utils.ErrorResponse{Message: "This auction is closed!"}.Init())
-	} else if listing.TimeLeft() < 1 {
-		return c.Status(410).JSON(utils.ErrorResponse{Message: "This auction is expired and closed!"}.Init())
-	} else if amount.Cmp(listing.Price) < 0 {
-		return c.Status(400).JSON(utils.ErrorResponse{Message: "Bid amount cannot be less than the bidding price!"}.Init())
-	} else if amount.Cmp(highestBid) <= 0 {
-		return c.Status(400).JSON(utils.ErrorResponse{Message: "Bid amount must be more than the highest bid!"}.Init())
+	quantity := 1
+	if createBidData.Quantity != nil {
+		quantity = *createBidData.Quantity
+	}
+	if bidErr := checkBiddable(listing, user, quantity); bidErr != nil {
+		return c.Status(bidErr.Code).JSON(utils.ErrorResponse{Message: bidErr.Message}.Init())
 	}
 
-	// Check for existing bid
+	// Bids on a listing are placed one at a time under a lock on it, so each is validated against
+	// the latest state of the listing and its bids. Holds are placed and the holds of outbid
+	// bidders released along with the bid itself
+	strategy := auctions.StrategyFor(listing)
+	var bidErr *auctions.BidError
 	bid := models.Bid{UserId: user.ID, ListingId: listing.ID}
-	db.Take(&bid, bid)
+	outbidBids := []models.Bid{}
+	leadingBefore := []models.Bid{}
+	leadingAfter := []models.Bid{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Take(&listing, listing.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			bidErr = &auctions.BidError{Code: 404, Message: "Listing does not exist!"}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if bidErr = checkBiddable(listing, user, quantity); bidErr != nil {
+			return nil
+		}
+
+		// Validate the bid against the listing's auction type
+		if bidErr = strategy.ValidateBid(tx, listing, models.Bid{UserId: user.ID, ListingId: listing.ID, Amount: amount, Quantity: quantity}); bidErr != nil {
+			return nil
+		}
+
+		// Bidders of sealed listings aren't told about other bids
+		if !listing.IsSealed() {
+			leadingBefore = auctions.LeadingBids(tx, listing)
+		}
+
+		if listing.RequiresDeposit {
+			if err := payments.HoldForBid(tx, user.ID, listing.ID, amount.Mul(decimal.NewFromInt(int64(quantity)))); err != nil {
+				return err
+			}
+		}
+
+		// Check for existing bid
+		tx.Take(&bid, bid)
+
+		// Create or update
+		bid.Amount = amount
+		bid.Quantity = quantity
+		if err := tx.Save(&bid).Error; err != nil {
+			return err
+		}
+
+		if listing.IsSealed() {
+			return nil
+		}
+		leadingAfter = auctions.LeadingBids(tx, listing)
+		if !strategy.ClosesOnBid() {
+			outbidBids = auctions.Outbid(leadingBefore, leadingAfter)
+			if listing.RequiresDeposit {
+				for _, outbidBid := range outbidBids {
+					if err := payments.Release(tx, outbidBid.UserId, listing.ID); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if bidErr != nil {
+		return c.Status(bidErr.Code).JSON(utils.ErrorResponse{Message: bidErr.Message}.Init())
+	} else if err == payments.ErrInsufficientFunds {
+		return c.Status(402).JSON(utils.ErrorResponse{Message: "Insufficient wallet balance for this bid!"}.Init())
+	} else if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+
+	// Notify bidders who are no longer leading
+	for _, outbidBid := range outbidBids {
+		go senders.NotifyOutbid(c.Locals("env"), db, outbidBid, listing)
+	}
+
+	bid = bid.Init(db)
+	if listing.IsSealed() {
+		bid.Sealed = true
+	}
 
-	// Create or update
-	bid.Amount = amount
-	db.Save(&bid)
+	// Push the bid to the listing's subscribers
+	var bidsCount int64
+	db.Model(&models.Bid{}).Where("listing_id = ?", listing.ID).Count(&bidsCount)
+	if listing.IsSealed() {
+		realtime.Publish(db, listing.ID, realtime.BidCreated, map[string]interface{}{"bid": bid.Seal(), "bids_count": bidsCount})
+	} else {
+		realtime.Publish(db, listing.ID, realtime.BidCreated, map[string]interface{}{"bid": bid, "bids_count": bidsCount})
+		if leadingAmount, ok := auctions.LeadingAmount(listing, leadingAfter); ok && auctions.LeadChanged(leadingBefore, leadingAfter) {
+			realtime.Publish(db, listing.ID, realtime.HighestBidChanged, map[string]interface{}{"highest_bid": leadingAmount})
+		}
+	}
+
+	if strategy.ClosesOnBid() {
+		auctions.Finalize(db, listing.ID)
+	}
 
 	response := schemas.BidResponseSchema{
 		ResponseSchema: schemas.ResponseSchema{Message: "Bid added to listing"}.Init(),
-		Data: bid.Init(db),
+		Data: bid,
 	}
 	return c.Status(201).JSON(response)
-}
\ No newline at end of file
+}
+
+// Checks that the listing takes bids from the user right now
+func checkBiddable(listing models.Listing, user *models.User, quantity int) *auctions.BidError {
+	if user.ID == listing.AuctioneerId {
+		return &auctions.BidError{Code: 403, Message: "You cannot bid your own product!"}
+	} else if !listing.Active || listing.FinalizedAt != nil || listing.State != models.ListingStatePublished {
+		return &auctions.BidError{Code: 410, Message: "This auction is closed!"}
+	} else if listing.TimeLeft() < 1 {
+		return &auctions.BidError{Code: 410, Message: "This auction is expired and closed!"}
+	} else if listing.IsUpcoming() {
+		return &auctions.BidError{Code: 400, Message: "This auction hasn't started yet!"}
+	} else if quantity > listing.Quantity {
+		return &auctions.BidError{Code: 400, Message: "Requested quantity exceeds the lot size!"}
+	}
+	return nil
+}
+
+// @Summary Buy a listing at its buy-now price
+// @Description This endpoint buys a listing immediately at its buy-now price, which closes the auction. The option is only available until a bid reaches the buy-now threshold.
+// @Failure 402 {object} utils.ErrorResponse
+// @Tags Listings
+// @Param slug path string true  "Listing Slug"
+// @Success 201 {object} schemas.AuctionResultResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 410 {object} utils.ErrorResponse
+// @Router /listings/detail/{slug}/buy-now [post]
+// @Security BearerAuth
+func BuyListingNow(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	listingSlug := c.Params("slug")
+
+	// Get Listing
+	listing := models.Listing{Slug: &listingSlug}
+	db.Scopes(models.PubliclyVisible).Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
+	}
+
+	result, buyErr := auctions.BuyNow(db, listing.ID, user.ID)
+	if buyErr != nil {
+		return c.Status(buyErr.Code).JSON(utils.ErrorResponse{Message: buyErr.Message}.Init())
+	}
+
+	response := schemas.AuctionResultResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing bought successfully"}.Init(),
+		Data:           result.Init(db),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Stream listing events
+// @Description This endpoint streams real-time events of a listing as Server-Sent Events: new bids (bid.created), highest bid changes (highest_bid.changed), closing date extensions (listing.extended) and closure (listing.closed). Since EventSource can't set headers, the access token and guest id can also be passed as 'token' and 'guestuserid' query params.
+// @Tags Listings
+// @Param slug path string true  "Listing Slug"
+// @Produce text/event-stream
+// @Success 200 {object} realtime.Event
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /listings/detail/{slug}/events [get]
+// @Security BearerAuth
+// @Security GuestUserAuth
+func GetListingEvents(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	listingSlug := c.Params("slug")
+
+	listing := models.Listing{Slug: &listingSlug}
+	db.Scopes(models.PubliclyVisible).Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	events, unsubscribe := realtime.Subscribe(listing.ID)
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		// Flushing fails once the client disconnects
+		fmt.Fprintf(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case event := <-events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			case <-keepAlive.C:
+				fmt.Fprintf(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}))
+	return nil
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kayprogrammer/bidout-auction-v7/config"
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+)
+
+// Stores a file uploaded straight to local storage with the data of an upload signature,
+// like a presigned PUT to a bucket. It's served from the same URL afterwards.
+// Only registered when files are kept locally, so it's left out of the API docs
+func UploadLocalFile(c *fiber.Ctx) error {
+	storage, ok := utils.FileStorage().(*utils.LocalStorage)
+	if !ok {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Not found!"}.Init())
+	}
+
+	key := c.Params("*")
+	timestamp, _ := strconv.ParseInt(c.Query("timestamp"), 10, 64)
+	if !storage.VerifyUpload(key, timestamp, c.Query("signature")) {
+		return c.Status(403).JSON(utils.ErrorResponse{Message: "Invalid or expired upload signature!"}.Init())
+	}
+	// The key's extension comes from the file type the client declared
+	contentType := c.Get(fiber.HeaderContentType)
+	if extension, ok := utils.ImageExtensions[contentType]; !ok || extension != strings.TrimPrefix(path.Ext(key), ".") {
+		return c.Status(415).JSON(utils.ErrorResponse{Message: "The file type doesn't match the one declared!"}.Init())
+	}
+	if err := storage.Upload(bytes.NewReader(c.Body()), key, contentType); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	return c.Status(201).JSON(schemas.ResponseSchema{Message: "File uploaded successfully"}.Init())
+}
+
+// @Summary Confirm an upload
+// @Description This endpoint checks that a file was uploaded with the file_upload_data returned for it, in the declared file type and within the size limit, then marks it ready. A new avatar or listing cover replaces the current one at this point. Pass file_upload_data.file_id as the id. A bad upload is deleted so it can be tried again, and files that aren't confirmed in time are deleted for good.
+// @Tags Files
+// @Param id path string true  "File ID"
+// @Success 200 {object} schemas.FileResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /files/{id}/confirm [post]
+// @Security BearerAuth
+func ConfirmUpload(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	file := models.File{}
+	db.Take(&file, "id = ?", uuid.FromStringOrNil(c.Params("id")))
+	if file.ID == uuid.Nil || file.UploaderId == nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "File does not exist!"}.Init())
+	}
+	if *file.UploaderId != user.ID {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This file doesn't belong to you!"}.Init())
+	}
+
+	storage := utils.FileStorage()
+	key := file.Key()
+	storedFile, err := storage.Stat(key)
+	if errors.Is(err, utils.ErrFileNotFound) {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "The file hasn't been uploaded yet!"}.Init())
+	} else if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	maxUploadSizeMB := config.GetConfig().MaxUploadSizeMB
+	if storedFile.ContentType != file.ResourceType {
+		storage.Delete(key)
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "The uploaded file doesn't match the declared file type!"}.Init())
+	}
+	if storedFile.Size > int64(maxUploadSizeMB)<<20 {
+		storage.Delete(key)
+		return c.Status(400).JSON(utils.ErrorResponse{Message: fmt.Sprintf("Files can't be larger than %dMB!", maxUploadSizeMB)}.Init())
+	}
+	replacedFiles, err := file.Confirm(db)
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	for _, replacedFile := range replacedFiles {
+		models.DeleteFile(db, replacedFile)
+	}
+
+	response := schemas.FileResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Upload confirmed"}.Init(),
+		Data: schemas.FileResponseDataSchema{
+			ID:           file.ID,
+			ResourceType: file.ResourceType,
+			Status:       file.Status,
+			Size:         storedFile.Size,
+			Url:          storage.Url(key),
+		},
+	}
+	return c.Status(200).JSON(response)
+}
@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/senders"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// Gets a listing awaiting review from the 'slug' path param
+func getPendingListing(c *fiber.Ctx, db *gorm.DB) (*models.Listing, int, *utils.ErrorResponse) {
+	listingSlug := c.Params("slug")
+	listing := models.Listing{Slug: &listingSlug}
+	db.Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		errResp := utils.ErrorResponse{Message: "Invalid listing!"}.Init()
+		return nil, 404, &errResp
+	}
+	if listing.State != models.ListingStatePendingReview {
+		errResp := utils.ErrorResponse{Message: "This listing is not pending review!"}.Init()
+		return nil, 400, &errResp
+	}
+	return &listing, 0, nil
+}
+
+// @Summary Retrieve the moderation queue
+// @Description This endpoint retrieves the listings waiting for review, oldest first. Staff only.
+// @Tags Moderation
+// @Success 200 {object} schemas.ListingsResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Router /moderation/listings [get]
+// @Security BearerAuth
+func GetModerationQueue(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	listings := []models.Listing{}
+	db.Preload(clause.Associations).Where("state = ?", models.ListingStatePendingReview).Order("updated_at ASC").Find(&listings)
+	for i := range listings {
+		listings[i] = listings[i].Init(db)
+	}
+
+	response := schemas.ListingsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Moderation queue fetched"}.Init(),
+		Data:           listings,
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Approve a listing
+// @Description This endpoint publishes a listing waiting for review and notifies the seller. Staff only.
+// @Tags Moderation
+// @Param slug path string true  "Listing Slug"
+// @Success 200 {object} schemas.ListingResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /moderation/listings/{slug}/approve [post]
+// @Security BearerAuth
+func ApproveListing(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	listing, errCode, errData := getPendingListing(c, db)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if listing.TimeLeftSeconds() < 1 {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing's closing date has passed!"}.Init())
+	}
+	moderated, err := listing.Moderate(db, map[string]interface{}{
+		"state":            models.ListingStatePublished,
+		"rejection_reason": nil,
+		"published_at":     time.Now().UTC(),
+	})
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	if !moderated {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing is not pending review!"}.Init())
+	}
+	db.Preload(clause.Associations).Take(listing, listing.ID)
+	go senders.NotifyModerationOutcome(c.Locals("env"), db, *listing)
+	go senders.AlertSavedSearches(c.Locals("env"), db, *listing)
+
+	response := schemas.ListingResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing approved"}.Init(),
+		Data:           listing.Init(db),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Reject a listing
+// @Description This endpoint rejects a listing waiting for review and emails the reason to the seller, who can update it and submit it again. Staff only.
+// @Tags Moderation
+// @Param slug path string true  "Listing Slug"
+// @Param reason body schemas.RejectListingSchema true "Rejection"
+// @Success 200 {object} schemas.ListingResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /moderation/listings/{slug}/reject [post]
+// @Security BearerAuth
+func RejectListing(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	validator := utils.Validator()
+
+	listing, errCode, errData := getPendingListing(c, db)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+
+	rejectData := schemas.RejectListingSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &rejectData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(rejectData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	moderated, err := listing.Moderate(db, map[string]interface{}{
+		"state":            models.ListingStateRejected,
+		"rejection_reason": rejectData.Reason,
+	})
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse{Message: "Something went wrong!"}.Init())
+	}
+	if !moderated {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing is not pending review!"}.Init())
+	}
+	db.Preload(clause.Associations).Take(listing, listing.ID)
+	go senders.NotifyModerationOutcome(c.Locals("env"), db, *listing)
+
+	response := schemas.ListingResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Listing rejected"}.Init(),
+		Data:           listing.Init(db),
+	}
+	return c.Status(200).JSON(response)
+}
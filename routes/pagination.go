@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+const defaultListingsLimit = 20
+
+func invalidEntry(field string, message string) *utils.ErrorResponse {
+	data := map[string]string{field: message}
+	errResp := utils.ErrorResponse{Message: "Invalid Entry", Data: &data}.Init()
+	return &errResp
+}
+
+// Builds a listing query from the request's query params
+func ListingQueryFromRequest(c *fiber.Ctx, db *gorm.DB) (models.ListingQuery, *utils.ErrorResponse) {
+	query := models.ListingQuery{}
+	queryData := schemas.ListingsQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		errResp := utils.ErrorResponse{Message: "Invalid query params!"}.Init()
+		return query, &errResp
+	}
+	if err := utils.Validator().Validate(queryData); err != nil {
+		return query, err
+	}
+
+	// 'quantity' is kept for older clients
+	query.Limit = queryData.Limit
+	if query.Limit == 0 {
+		query.Limit = queryData.Quantity
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultListingsLimit
+	}
+	query.Cursor = queryData.Cursor
+	query.Status = queryData.Status
+	if queryData.State != "" {
+		query.States = []string{queryData.State}
+	}
+	query.Archived = queryData.Archived
+	query.Sort = queryData.Sort
+	if query.Sort == "" {
+		query.Sort = models.SortNewest
+	}
+
+	if queryData.Category == "other" {
+		query.Uncategorized = true
+	} else if queryData.Category != "" {
+		category := models.Category{Slug: &queryData.Category}
+		db.Take(&category, category)
+		if category.ID == uuid.Nil {
+			return query, invalidEntry("category", "Invalid category!")
+		}
+		query.CategoryIds = models.CategoryDescendantIds(db, category.ID)
+	}
+
+	if queryData.MinPrice > 0 {
+		minPrice := utils.DecimalParser(queryData.MinPrice)
+		query.MinPrice = &minPrice
+	}
+	if queryData.MaxPrice > 0 {
+		maxPrice := utils.DecimalParser(queryData.MaxPrice)
+		if query.MinPrice != nil && maxPrice.LessThan(*query.MinPrice) {
+			return query, invalidEntry("max_price", "Value is too small!")
+		}
+		query.MaxPrice = &maxPrice
+	}
+	if queryData.Auctioneer != "" {
+		auctioneerId := uuid.FromStringOrNil(queryData.Auctioneer)
+		query.AuctioneerId = &auctioneerId
+	}
+	attributeFilters, errData := attributeFiltersFromRequest(c)
+	if errData != nil {
+		return query, errData
+	}
+	query.Attributes = attributeFilters
+	return query, nil
+}
+
+// Reads attribute filters from query params like 'attr.storage=128GB', 'attr.year.min=2015'
+// and 'attr.mileage.max=50000'
+func attributeFiltersFromRequest(c *fiber.Ctx) ([]models.AttributeFilter, *utils.ErrorResponse) {
+	filters := []models.AttributeFilter{}
+	for key, value := range c.Queries() {
+		if !strings.HasPrefix(key, "attr.") {
+			continue
+		}
+		name := strings.TrimPrefix(key, "attr.")
+		operator := models.AttributeEquals
+		if strings.HasSuffix(name, ".min") {
+			name, operator = strings.TrimSuffix(name, ".min"), models.AttributeAtLeast
+		} else if strings.HasSuffix(name, ".max") {
+			name, operator = strings.TrimSuffix(name, ".max"), models.AttributeAtMost
+		}
+		if !utils.AttributeNameRegex.MatchString(name) {
+			return nil, invalidEntry(key, "Invalid attribute!")
+		}
+		if operator != models.AttributeEquals {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, invalidEntry(key, "Must be a number!")
+			}
+		}
+		filters = append(filters, models.AttributeFilter{Name: name, Operator: operator, Value: value})
+	}
+	return filters, nil
+}
+
+// Runs a listing query and returns the page with its pagination metadata
+func PaginateListings(db *gorm.DB, query models.ListingQuery) ([]models.Listing, schemas.PaginationMetaSchema, *utils.ErrorResponse) {
+	listings, nextCursor, err := query.Find(db)
+	if err != nil {
+		return nil, schemas.PaginationMetaSchema{}, invalidEntry("cursor", "Invalid cursor!")
+	}
+	meta := schemas.PaginationMetaSchema{NextCursor: nextCursor, Total: query.Count(db), Limit: query.Limit}
+	return listings, meta, nil
+}
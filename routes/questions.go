@@ -0,0 +1,212 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/senders"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+const defaultQuestionsLimit = 10
+
+// Gets a question of a listing from the 'id' path param
+func getListingQuestion(c *fiber.Ctx, db *gorm.DB, listingId *uuid.UUID) (*models.ListingQuestion, *utils.ErrorResponse) {
+	questionId, err := uuid.FromString(c.Params("id"))
+	question := models.ListingQuestion{}
+	if err == nil {
+		questionsQuery := db.Preload("UserObj")
+		if listingId != nil {
+			questionsQuery = questionsQuery.Where("listing_id = ? AND hidden = ?", *listingId, false)
+		}
+		questionsQuery.Take(&question, questionId)
+	}
+	if question.ID == uuid.Nil {
+		errResp := utils.ErrorResponse{Message: "Invalid question!"}.Init()
+		return nil, &errResp
+	}
+	return &question, nil
+}
+
+// @Summary Ask a question about a listing
+// @Description This endpoint adds a public question to a listing and emails the seller. Only the seller can answer it.
+// @Tags Listings
+// @Param slug path string true  "Listing Slug"
+// @Param question body schemas.AskListingQuestionSchema true "Question"
+// @Success 201 {object} schemas.ListingQuestionResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /listings/detail/{slug}/questions [post]
+// @Security BearerAuth
+func AskListingQuestion(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	listingSlug := c.Params("slug")
+
+	listing := models.Listing{Slug: &listingSlug}
+	db.Scopes(models.PubliclyVisible).Take(&listing, listing)
+	if listing.ID == uuid.Nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Listing does not exist!"}.Init())
+	}
+	if listing.AuctioneerId == user.ID {
+		return c.Status(403).JSON(utils.ErrorResponse{Message: "You cannot ask about your own product!"}.Init())
+	}
+	if listing.State != models.ListingStatePublished || listing.IsClosed() {
+		return c.Status(400).JSON(utils.ErrorResponse{Message: "This listing is closed for questions!"}.Init())
+	}
+
+	questionData := schemas.AskListingQuestionSchema{}
+	if errCode, errData := DecodeJSONBody(c, &questionData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := utils.Validator().Validate(questionData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	question := models.ListingQuestion{ListingId: listing.ID, UserId: user.ID, Question: questionData.Question}
+	db.Create(&question)
+	question.UserObj = *user
+	go senders.NotifyQuestionAsked(c.Locals("env"), db, question, listing)
+
+	response := schemas.ListingQuestionResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Question asked successfully"}.Init(),
+		Data:           question.Init(db),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Answer a question about a listing
+// @Description This endpoint answers a question on one of the current user's listings and emails the person who asked. Sending another answer replaces the previous one.
+// @Tags Auctioneer
+// @Param slug path string true  "Listing Slug"
+// @Param id path string true  "Question ID"
+// @Param answer body schemas.AnswerListingQuestionSchema true "Answer"
+// @Success 200 {object} schemas.ListingQuestionResponseSchema
+// @Failure 400 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /auctioneer/listings/{slug}/questions/{id}/answer [post]
+// @Security BearerAuth
+func AnswerListingQuestion(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	listing, errCode, errData := getAuctioneerListing(c, db, user)
+	if errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	question, errData := getListingQuestion(c, db, &listing.ID)
+	if errData != nil {
+		return c.Status(404).JSON(errData)
+	}
+
+	answerData := schemas.AnswerListingQuestionSchema{}
+	if errCode, errData := DecodeJSONBody(c, &answerData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := utils.Validator().Validate(answerData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	question.Answer = &answerData.Answer
+	if question.AnsweredAt == nil {
+		answeredAt := time.Now().UTC()
+		question.AnsweredAt = &answeredAt
+	}
+	db.Model(question).Updates(map[string]interface{}{"answer": question.Answer, "answered_at": question.AnsweredAt})
+	go senders.NotifyQuestionAnswered(c.Locals("env"), db, *question, *listing)
+
+	response := schemas.ListingQuestionResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Question answered successfully"}.Init(),
+		Data:           question.Init(db),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Retrieve questions for moderation
+// @Description This endpoint retrieves the questions asked on all listings, latest first. Pass hidden=true to get the hidden ones instead. Staff only.
+// @Tags Moderation
+// @Param hidden query bool false  "Hidden Questions"
+// @Param page query int false  "Page Number"
+// @Param limit query int false  "Page Size (max 50)"
+// @Success 200 {object} schemas.ListingQuestionsResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /moderation/questions [get]
+// @Security BearerAuth
+func GetModerationQuestions(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	queryData := schemas.QuestionsQuerySchema{}
+	if err := c.QueryParser(&queryData); err != nil {
+		return c.Status(422).JSON(utils.ErrorResponse{Message: "Invalid query params!"}.Init())
+	}
+	if err := utils.Validator().Validate(queryData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+	limit := queryData.Limit
+	if limit == 0 {
+		limit = defaultQuestionsLimit
+	}
+	page := queryData.Page
+	if page == 0 {
+		page = 1
+	}
+
+	questions, total := models.FindListingQuestions(db, map[string]interface{}{"hidden": queryData.Hidden}, page, limit)
+
+	response := schemas.ListingQuestionsResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Questions fetched"}.Init(),
+		Data:           questions,
+		Meta:           schemas.SearchMetaSchema{Total: total, Page: page, Limit: limit},
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Hide or show a question
+// @Description This endpoint takes a question off its listing, or puts it back. Staff only.
+// @Tags Moderation
+// @Param id path string true  "Question ID"
+// @Param question body schemas.ModerateListingQuestionSchema true "Moderation"
+// @Success 200 {object} schemas.ListingQuestionResponseSchema
+// @Failure 403 {object} utils.ErrorResponse
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /moderation/questions/{id} [patch]
+// @Security BearerAuth
+func ModerateListingQuestion(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	question, errData := getListingQuestion(c, db, nil)
+	if errData != nil {
+		return c.Status(404).JSON(errData)
+	}
+
+	moderationData := schemas.ModerateListingQuestionSchema{}
+	if errCode, errData := DecodeJSONBody(c, &moderationData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := utils.Validator().Validate(moderationData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	question.Hidden = *moderationData.Hidden
+	question.HiddenReason = nil
+	if question.Hidden {
+		question.HiddenReason = moderationData.Reason
+	}
+	db.Model(question).Updates(map[string]interface{}{"hidden": question.Hidden, "hidden_reason": question.HiddenReason})
+
+	response := schemas.ListingQuestionResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Question moderated successfully"}.Init(),
+		Data:           question.Init(db),
+	}
+	return c.Status(200).JSON(response)
+}
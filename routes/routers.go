@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	midw "github.com/kayprogrammer/bidout-auction-v7/authentication"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
 )
 
 func SetupRoutes(app *fiber.App) {
@@ -31,13 +32,24 @@ func SetupRoutes(app *fiber.App) {
 	// Listings Routes
 	listingsRouter := api.Group("/listings")
 	listingsRouter.Get("", midw.ClientMiddleware, GetListings)
-	listingsRouter.Get("/detail/:slug", GetListing)
+	listingsRouter.Get("/search", midw.ClientMiddleware, SearchListings)
+	listingsRouter.Get("/detail/:slug", midw.OptionalClientMiddleware, GetListing)
 	listingsRouter.Get("/watchlist", midw.ClientMiddleware, GetWatchlistListings)
 	listingsRouter.Post("/watchlist", midw.ClientMiddleware, AddOrRemoveWatchlistListing)
 	listingsRouter.Get("/categories", GetCategories)
+	listingsRouter.Post("/categories", midw.AuthMiddleware, midw.StaffMiddleware, CreateCategory)
+	listingsRouter.Get("/categories/tree", GetCategoryTree)
 	listingsRouter.Get("/categories/:slug", GetCategoryListings)
+	listingsRouter.Patch("/categories/:slug", midw.AuthMiddleware, midw.StaffMiddleware, UpdateCategory)
+	listingsRouter.Delete("/categories/:slug", midw.AuthMiddleware, midw.StaffMiddleware, DeleteCategory)
+	listingsRouter.Get("/categories/:slug/attributes", GetCategoryAttributes)
+	listingsRouter.Put("/categories/:slug/attributes", midw.AuthMiddleware, midw.StaffMiddleware, UpdateCategoryAttributes)
 	listingsRouter.Get("/detail/:slug/bids", GetListingBids)
 	listingsRouter.Post("/detail/:slug/bids", midw.AuthMiddleware, CreateBid)
+	listingsRouter.Get("/detail/:slug/revisions", GetListingRevisions)
+	listingsRouter.Post("/detail/:slug/questions", midw.AuthMiddleware, AskListingQuestion)
+	listingsRouter.Post("/detail/:slug/buy-now", midw.AuthMiddleware, BuyListingNow)
+	listingsRouter.Get("/detail/:slug/events", midw.QueryAuthMiddleware, midw.ClientMiddleware, GetListingEvents)
 
 	// Auctioneer Routes
 	auctioneerRouter := api.Group("/auctioneer")
@@ -45,6 +57,65 @@ func SetupRoutes(app *fiber.App) {
 	auctioneerRouter.Put("", midw.AuthMiddleware, UpdateProfile)
 	auctioneerRouter.Get("/listings", midw.AuthMiddleware, GetAuctioneerListings)
 	auctioneerRouter.Post("/listings", midw.AuthMiddleware, CreateListing)
+	auctioneerRouter.Post("/listings/import", midw.AuthMiddleware, ImportListings)
 	auctioneerRouter.Patch("/listings/:slug", midw.AuthMiddleware, UpdateListing)
+	auctioneerRouter.Delete("/listings/:slug", midw.AuthMiddleware, DeleteListing)
+	auctioneerRouter.Post("/listings/:slug/submit", midw.AuthMiddleware, SubmitListing)
+	auctioneerRouter.Post("/listings/:slug/cancel", midw.AuthMiddleware, CancelListing)
+	auctioneerRouter.Post("/listings/:slug/relist", midw.AuthMiddleware, RelistListing)
 	auctioneerRouter.Get("/listings/:slug/bids", midw.AuthMiddleware, GetAuctioneerListingBids)
+	auctioneerRouter.Post("/listings/:slug/images", midw.AuthMiddleware, AddListingImages)
+	auctioneerRouter.Put("/listings/:slug/images", midw.AuthMiddleware, ReorderListingImages)
+	auctioneerRouter.Delete("/listings/:slug/images/:id", midw.AuthMiddleware, RemoveListingImage)
+	auctioneerRouter.Post("/listings/:slug/questions/:id/answer", midw.AuthMiddleware, AnswerListingQuestion)
+	auctioneerRouter.Get("/analytics", midw.AuthMiddleware, GetAuctioneerAnalytics)
+	auctioneerRouter.Get("/notifications", midw.AuthMiddleware, GetNotificationPreferences)
+	auctioneerRouter.Put("/notifications", midw.AuthMiddleware, UpdateNotificationPreferences)
+
+	// Moderation Routes
+	moderationRouter := api.Group("/moderation", midw.AuthMiddleware, midw.StaffMiddleware)
+	moderationRouter.Get("/listings", GetModerationQueue)
+	moderationRouter.Post("/listings/:slug/approve", ApproveListing)
+	moderationRouter.Post("/listings/:slug/reject", RejectListing)
+	moderationRouter.Get("/listings/:slug/conversations", GetListingConversations)
+	moderationRouter.Get("/questions", GetModerationQuestions)
+	moderationRouter.Patch("/questions/:id", ModerateListingQuestion)
+
+	// Saved Searches Routes
+	savedSearchesRouter := api.Group("/saved-searches")
+	savedSearchesRouter.Get("", midw.AuthMiddleware, GetSavedSearches)
+	savedSearchesRouter.Post("", midw.AuthMiddleware, CreateSavedSearch)
+	savedSearchesRouter.Get("/unsubscribe/:token", GetUnsubscribeSavedSearch)
+	savedSearchesRouter.Post("/unsubscribe/:token", UnsubscribeSavedSearch)
+	savedSearchesRouter.Patch("/:id", midw.AuthMiddleware, UpdateSavedSearch)
+	savedSearchesRouter.Delete("/:id", midw.AuthMiddleware, DeleteSavedSearch)
+
+	// Conversations Routes
+	conversationsRouter := api.Group("/conversations", midw.AuthMiddleware)
+	conversationsRouter.Get("", GetConversations)
+	conversationsRouter.Post("", StartConversation)
+	conversationsRouter.Get("/unread", GetUnreadMessagesCount)
+	conversationsRouter.Get("/:id/messages", GetConversationMessages)
+	conversationsRouter.Post("/:id/messages", SendMessage)
+
+	// Currencies Routes
+	currenciesRouter := api.Group("/currencies")
+	currenciesRouter.Get("", GetExchangeRates)
+	currenciesRouter.Put("", midw.AuthMiddleware, midw.StaffMiddleware, UpdateExchangeRates)
+
+	// Wallet Routes
+	walletRouter := api.Group("/wallet")
+	walletRouter.Get("", midw.AuthMiddleware, GetWallet)
+	walletRouter.Post("/deposit", midw.AuthMiddleware, Deposit)
+	walletRouter.Post("/withdraw", midw.AuthMiddleware, Withdraw)
+
+	// Files Routes
+	filesRouter := api.Group("/files", midw.AuthMiddleware)
+	filesRouter.Post("/:id/confirm", ConfirmUpload)
+
+	// Local Storage Routes (files are served and uploaded outside the API)
+	if storage, ok := utils.FileStorage().(*utils.LocalStorage); ok {
+		app.Static(utils.LocalMediaPath, storage.Dir())
+		app.Put(utils.LocalMediaPath+"/*", UploadLocalFile)
+	}
 }
\ No newline at end of file
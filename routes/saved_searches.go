@@ -0,0 +1,236 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// Gets a saved search of the current user from the 'id' path param
+func getUserSavedSearch(c *fiber.Ctx, db *gorm.DB, user *models.User) *models.SavedSearch {
+	searchId := uuid.FromStringOrNil(c.Params("id"))
+	search := models.SavedSearch{}
+	db.Preload("CategoryObj").Where("id = ? AND user_id = ?", searchId, user.ID).Take(&search)
+	if search.ID == uuid.Nil {
+		return nil
+	}
+	return &search
+}
+
+// Checks the fields of a saved search that the validator can't
+func validateSavedSearch(db *gorm.DB, search *models.SavedSearch, categorySlug *string) *utils.ErrorResponse {
+	if models.PrefixTsQuery(search.Query) == "" {
+		return invalidEntry("query", "Enter at least one word to search for!")
+	}
+	if categorySlug != nil {
+		search.CategoryId = nil
+		if *categorySlug != "" {
+			category := models.Category{Slug: categorySlug}
+			db.Take(&category, category)
+			if category.ID == uuid.Nil {
+				return invalidEntry("category", "Invalid category!")
+			}
+			search.CategoryId = &category.ID
+		}
+	}
+	if search.MinPrice != nil && search.MaxPrice != nil && search.MaxPrice.LessThan(*search.MinPrice) {
+		return invalidEntry("max_price", "Value is too small!")
+	}
+	return nil
+}
+
+// @Summary Retrieve saved searches
+// @Description This endpoint retrieves the current user's saved searches.
+// @Tags Saved Searches
+// @Success 200 {object} schemas.SavedSearchesResponseSchema
+// @Router /saved-searches [get]
+// @Security BearerAuth
+func GetSavedSearches(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	searches := []models.SavedSearch{}
+	db.Preload("CategoryObj").Where("user_id = ?", user.ID).Order("created_at DESC").Find(&searches)
+	for i := range searches {
+		searches[i] = searches[i].Init()
+	}
+
+	response := schemas.SavedSearchesResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Saved searches fetched"}.Init(),
+		Data:           searches,
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Save a search
+// @Description This endpoint saves a search. New listings that match it are emailed instantly or in a daily digest, depending on the frequency.
+// @Tags Saved Searches
+// @Param search body schemas.CreateSavedSearchSchema true "Create Saved Search"
+// @Success 201 {object} schemas.SavedSearchResponseSchema
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /saved-searches [post]
+// @Security BearerAuth
+func CreateSavedSearch(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	searchData := schemas.CreateSavedSearchSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &searchData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(searchData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	search := models.SavedSearch{
+		UserId:    user.ID,
+		Name:      searchData.Name,
+		Query:     searchData.Query,
+		Frequency: searchData.Frequency,
+	}
+	if search.Frequency == "" {
+		search.Frequency = models.AlertInstant
+	}
+	if searchData.MinPrice != nil {
+		minPrice := utils.DecimalParser(*searchData.MinPrice)
+		search.MinPrice = &minPrice
+	}
+	if searchData.MaxPrice != nil {
+		maxPrice := utils.DecimalParser(*searchData.MaxPrice)
+		search.MaxPrice = &maxPrice
+	}
+	if errData := validateSavedSearch(db, &search, searchData.Category); errData != nil {
+		return c.Status(422).JSON(errData)
+	}
+	db.Create(&search)
+	db.Preload("CategoryObj").Take(&search, search.ID)
+
+	response := schemas.SavedSearchResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Search saved successfully"}.Init(),
+		Data:           search.Init(),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Update a saved search
+// @Description This endpoint updates a saved search of the current user. Pass an empty category to clear it.
+// @Tags Saved Searches
+// @Param id path string true  "Saved Search ID"
+// @Param search body schemas.UpdateSavedSearchSchema true "Update Saved Search"
+// @Success 200 {object} schemas.SavedSearchResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /saved-searches/{id} [patch]
+// @Security BearerAuth
+func UpdateSavedSearch(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	search := getUserSavedSearch(c, db, user)
+	if search == nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Saved search does not exist!"}.Init())
+	}
+
+	searchData := schemas.UpdateSavedSearchSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &searchData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(searchData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	categorySlug := searchData.Category
+	searchData.Category = nil
+	utils.AssignFields(searchData, search)
+	if errData := validateSavedSearch(db, search, categorySlug); errData != nil {
+		return c.Status(422).JSON(errData)
+	}
+	search.CategoryObj = nil
+	db.Save(search)
+	db.Preload("CategoryObj").Take(search, search.ID)
+
+	response := schemas.SavedSearchResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Saved search updated"}.Init(),
+		Data:           search.Init(),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Delete a saved search
+// @Description This endpoint deletes a saved search of the current user.
+// @Tags Saved Searches
+// @Param id path string true  "Saved Search ID"
+// @Success 200 {object} schemas.ResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /saved-searches/{id} [delete]
+// @Security BearerAuth
+func DeleteSavedSearch(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	search := getUserSavedSearch(c, db, user)
+	if search == nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Saved search does not exist!"}.Init())
+	}
+	db.Delete(search)
+	return c.Status(200).JSON(schemas.ResponseSchema{Message: "Saved search deleted"}.Init())
+}
+
+// Returns the saved search an unsubscribe link points to
+func getUnsubscribeSavedSearch(c *fiber.Ctx, db *gorm.DB) *models.SavedSearch {
+	search := models.SavedSearch{}
+	db.Preload("CategoryObj").Where("unsubscribe_token = ?", c.Params("token")).Take(&search)
+	if search.ID == uuid.Nil {
+		return nil
+	}
+	return &search
+}
+
+// @Summary Confirm unsubscribing from a saved search
+// @Description This endpoint returns the saved search of an unsubscribe link so the page it opens can ask for confirmation. Nothing is deleted, since mail scanners and link previews follow links on their own. No login is needed.
+// @Tags Saved Searches
+// @Param token path string true  "Unsubscribe Token"
+// @Success 200 {object} schemas.SavedSearchResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /saved-searches/unsubscribe/{token} [get]
+func GetUnsubscribeSavedSearch(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	search := getUnsubscribeSavedSearch(c, db)
+	if search == nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid unsubscribe link!"}.Init())
+	}
+	response := schemas.SavedSearchResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Confirm to unsubscribe from this saved search"}.Init(),
+		Data:           search.Init(),
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Unsubscribe from a saved search
+// @Description This endpoint deletes a saved search using the token from the unsubscribe link of its alert emails, once the user confirms it. No login is needed.
+// @Tags Saved Searches
+// @Param token path string true  "Unsubscribe Token"
+// @Success 200 {object} schemas.ResponseSchema
+// @Failure 404 {object} utils.ErrorResponse
+// @Router /saved-searches/unsubscribe/{token} [post]
+func UnsubscribeSavedSearch(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+
+	search := getUnsubscribeSavedSearch(c, db)
+	if search == nil {
+		return c.Status(404).JSON(utils.ErrorResponse{Message: "Invalid unsubscribe link!"}.Init())
+	}
+	db.Delete(search)
+	return c.Status(200).JSON(schemas.ResponseSchema{Message: "Unsubscribed successfully"}.Init())
+}
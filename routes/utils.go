@@ -1,8 +1,14 @@
 package routes
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
 	"github.com/satori/go.uuid"
 )
 
@@ -26,4 +32,58 @@ func GetClient(c *fiber.Ctx) *Client {
 	return &client
 }
 
-// func ParseRequestBody()
\ No newline at end of file
+// Identifies who is viewing a page: the client if there's one, else a hash of their IP address
+func ViewerKey(c *fiber.Ctx) string {
+	if client := GetClient(c); client != nil {
+		return client.ID.String()
+	}
+	hash := sha256.Sum256([]byte(c.IP()))
+	return hex.EncodeToString(hash[:])
+}
+
+// Returns the currency a client wants prices displayed in, from the 'currency'
+// query param or the 'Accept-Currency' header
+func DisplayCurrency(c *fiber.Ctx) string {
+	currency := c.Query("currency")
+	if currency == "" {
+		currency = c.Get("Accept-Currency")
+	}
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// Flags the listings on the client's watchlist
+func SetWatchlistFlags(db *gorm.DB, client *Client, listings []models.Listing) {
+	if client == nil || len(listings) == 0 {
+		return
+	}
+	listingIds := []uuid.UUID{}
+	for _, listing := range listings {
+		listingIds = append(listingIds, listing.ID)
+	}
+	watchedIds := []uuid.UUID{}
+	db.Model(&models.Watchlist{}).Where("listing_id IN ? AND (user_id = ? OR guestuser_id = ?)", listingIds, client.ID, client.ID).Pluck("listing_id", &watchedIds)
+	watched := map[uuid.UUID]bool{}
+	for _, listingId := range watchedIds {
+		watched[listingId] = true
+	}
+	for i := range listings {
+		listings[i].Watchlist = watched[listings[i].ID]
+	}
+}
+
+// func ParseRequestBody()
+
+// Sends clients that used an old slug to the current URL of a renamed listing or category.
+// The canonical slug is in the body too, for clients that don't follow redirects
+func SlugMoved(c *fiber.Ctx, oldSlug string, canonicalSlug string) error {
+	location := strings.TrimSuffix(c.Path(), oldSlug) + canonicalSlug
+	if queryString := string(c.Request().URI().QueryString()); queryString != "" {
+		location += "?" + queryString
+	}
+	c.Location(location)
+	response := schemas.SlugMovedResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "This slug has changed"}.Init(),
+		Data:           schemas.SlugMovedResponseDataSchema{CanonicalSlug: canonicalSlug},
+	}
+	return c.Status(301).JSON(response)
+}
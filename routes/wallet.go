@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/payments"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// @Summary Get Wallet
+// @Description This endpoint retrieves the current user's available and held balances with their recent wallet transactions.
+// @Tags Wallet
+// @Success 200 {object} schemas.WalletResponseSchema
+// @Router /wallet [get]
+// @Security BearerAuth
+func GetWallet(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+
+	transactions := []models.LedgerTransaction{}
+	db.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(20).Find(&transactions)
+	for i := range transactions {
+		transactions[i] = transactions[i].Init()
+	}
+
+	response := schemas.WalletResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Wallet fetched"}.Init(),
+		Data: schemas.WalletResponseDataSchema{
+			Available:    payments.AvailableBalance(db, user.ID),
+			Held:         payments.HeldBalance(db, user.ID, nil),
+			Transactions: transactions,
+		},
+	}
+	return c.Status(200).JSON(response)
+}
+
+// @Summary Deposit into wallet
+// @Description This endpoint charges the user through the payment provider and credits their wallet. Listings that require a deposit can only be bid on with available wallet funds.
+// @Tags Wallet
+// @Param amount body schemas.WalletAmountSchema true "Deposit"
+// @Success 201 {object} schemas.WalletTransactionResponseSchema
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /wallet/deposit [post]
+// @Security BearerAuth
+func Deposit(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	depositData := schemas.WalletAmountSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &depositData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(depositData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	transaction, err := payments.Deposit(db, user.ID, utils.DecimalParser(depositData.Amount))
+	if err != nil {
+		return c.Status(502).JSON(utils.ErrorResponse{Message: "Payment failed!"}.Init())
+	}
+
+	response := schemas.WalletTransactionResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Deposit successful"}.Init(),
+		Data:           transaction.Init(),
+	}
+	return c.Status(201).JSON(response)
+}
+
+// @Summary Withdraw from wallet
+// @Description This endpoint pays available wallet funds back to the user. Funds held for bids can't be withdrawn. If the payout fails the funds go back to the available balance.
+// @Tags Wallet
+// @Param amount body schemas.WalletAmountSchema true "Withdrawal"
+// @Success 201 {object} schemas.WalletTransactionResponseSchema
+// @Failure 402 {object} utils.ErrorResponse
+// @Failure 422 {object} utils.ErrorResponse
+// @Router /wallet/withdraw [post]
+// @Security BearerAuth
+func Withdraw(c *fiber.Ctx) error {
+	db := c.Locals("db").(*gorm.DB)
+	user := c.Locals("user").(*models.User)
+	validator := utils.Validator()
+
+	withdrawalData := schemas.WalletAmountSchema{}
+
+	// Validate request
+	if errCode, errData := DecodeJSONBody(c, &withdrawalData); errData != nil {
+		return c.Status(errCode).JSON(errData)
+	}
+	if err := validator.Validate(withdrawalData); err != nil {
+		return c.Status(422).JSON(err)
+	}
+
+	transaction, err := payments.Withdraw(db, user.ID, utils.DecimalParser(withdrawalData.Amount))
+	if err == payments.ErrInsufficientFunds {
+		return c.Status(402).JSON(utils.ErrorResponse{Message: "Insufficient wallet balance!"}.Init())
+	} else if err != nil {
+		return c.Status(502).JSON(utils.ErrorResponse{Message: "Payment failed!"}.Init())
+	}
+
+	response := schemas.WalletTransactionResponseSchema{
+		ResponseSchema: schemas.ResponseSchema{Message: "Withdrawal successful"}.Init(),
+		Data:           transaction.Init(),
+	}
+	return c.Status(201).JSON(response)
+}
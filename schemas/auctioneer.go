@@ -17,8 +17,21 @@ type CreateListingSchema struct {
 	Desc        	string	          `json:"desc" validate:"required" example:"Product description"`
 	Category    	string	          `json:"category" validate:"required" example:"category_slug"`
 	Price       	float64	 		  `json:"price" validate:"required,gt=0" example:"1000.00"`
+	Currency		string			  `json:"currency" validate:"omitempty,currency_code" example:"USD"`
+	Quantity		*int			  `json:"quantity" validate:"omitempty,gt=0" example:"1"`
+	PricingRule		string			  `json:"pricing_rule" validate:"omitempty,oneof=uniform discriminatory" example:"discriminatory"`
+	StartsAt		*string			  `json:"starts_at" validate:"omitempty,date,closing_date_validator" example:"2006-01-02T15:04:05.000Z"`
 	ClosingDate 	string	 		  `json:"closing_date" validate:"required,date,closing_date_validator" example:"2006-01-02T15:04:05.000Z"`
 	FileType    	string	          `json:"file_type" validate:"required,file_type_validator" example:"image/jpeg"`
+	AuctionType		string			  `json:"auction_type" validate:"omitempty,oneof=english sealed_first_price sealed_second_price dutch reverse" example:"english"`
+	FloorPrice		*float64		  `json:"floor_price" validate:"required_if=AuctionType dutch,omitempty,gt=0,ltfield=Price" example:"500.00"`
+	PriceDecrement	*float64		  `json:"price_decrement" validate:"required_if=AuctionType dutch,omitempty,gt=0" example:"50.00"`
+	DecrementMinutes *int			  `json:"decrement_minutes" validate:"required_if=AuctionType dutch,omitempty,gt=0" example:"60"`
+	BuyNowPrice		*float64		  `json:"buy_now_price" validate:"omitempty,gtfield=Price" example:"5000.00"`
+	RequiresDeposit	bool			  `json:"requires_deposit" example:"false"`
+	AutoRelist		int				  `json:"auto_relist" validate:"gte=0,lte=10" example:"2"`
+	Attributes		map[string]interface{} `json:"attributes"`
+	Draft			bool			  `json:"draft" example:"false"`
 }
 
 type UpdateListingSchema struct {
@@ -26,9 +39,54 @@ type UpdateListingSchema struct {
 	Desc        *string          `json:"desc" example:"Product description"`
 	Category    *string          `json:"category" example:"category_slug"`
 	Price       *float64 		 `json:"price" validate:"omitempty,gt=0" example:"1000.00"`
+	StartsAt	*string			 `json:"starts_at" validate:"omitempty,date,closing_date_validator" example:"2006-01-02T15:04:05.000Z"`
 	ClosingDate *string       	 `json:"closing_date" validate:"omitempty,date,closing_date_validator" example:"2006-01-02T15:04:05.000Z"`
 	FileType    *string          `json:"file_type" validate:"omitempty,file_type_validator" example:"image/jpeg"`
 	Active      *bool            `json:"active" example:"true"`
+	BuyNowPrice *float64		 `json:"buy_now_price" validate:"omitempty,gt=0" example:"5000.00"`
+	RemoveBuyNow *bool			 `json:"remove_buy_now" example:"false"`
+	AutoRelist	*int			 `json:"auto_relist" validate:"omitempty,gte=0,lte=10" example:"2"`
+	Attributes	map[string]interface{} `json:"attributes"`
+}
+
+type AddListingImagesSchema struct {
+	FileTypes		[]string		  `json:"file_types" validate:"required,dive,file_type_validator" example:"image/jpeg,image/png"`
+}
+
+type ReorderListingImagesSchema struct {
+	ImageIds		[]string		  `json:"image_ids" validate:"required,dive,uuid" example:"2b3bd817-135e-41bd-9781-33807c92ff40,8e5d5a8d-6fbb-4d4d-a2e0-5f1e9a6b1c3d"`
+}
+
+type RelistListingSchema struct {
+	Price			*float64		  `json:"price" validate:"omitempty,gt=0" example:"800.00"`
+	StartsAt		*string			  `json:"starts_at" validate:"omitempty,date,closing_date_validator" example:"2006-01-02T15:04:05.000Z"`
+	ClosingDate		string			  `json:"closing_date" validate:"required,date,closing_date_validator" example:"2006-01-02T15:04:05.000Z"`
+}
+
+type RejectListingSchema struct {
+	Reason		string		`json:"reason" validate:"required,max=500" example:"The photos don't show the product"`
+}
+
+type CancelListingSchema struct {
+	Reason		string		`json:"reason" validate:"required,max=500" example:"The item was damaged"`
+}
+
+type UpdateNotificationPreferencesSchema struct {
+	Preferences		map[string]bool	  `json:"preferences" validate:"required" example:"outbid:false,ending_soon:true"`
+}
+
+type ImportListingsSchema struct {
+	Listings		[]CreateListingSchema		`json:"listings" validate:"required"`
+}
+
+// QUERY PARAMS SCHEMAS
+type AnalyticsQuerySchema struct {
+	Days			int				`query:"days" json:"days" validate:"omitempty,gt=0,lte=90"`
+	Listing			string			`query:"listing" json:"listing"`
+}
+
+type ImportListingsQuerySchema struct {
+	Mode			string			`query:"mode" json:"mode" validate:"omitempty,oneof=all_or_nothing partial"`
 }
 
 // RESPONSE BODY SCHEMAS
@@ -63,3 +121,48 @@ type CreateListingResponseSchema struct {
 	ResponseSchema
 	Data CreateListingResponseDataSchema `json:"data"`
 }
+
+type ListingImageResponseDataSchema struct {
+	models.ListingImage
+	FileUploadData utils.SignatureFormat `json:"file_upload_data"`
+}
+
+type ListingImagesResponseSchema struct {
+	ResponseSchema
+	Data []ListingImageResponseDataSchema `json:"data"`
+}
+
+type ListingResponseSchema struct {
+	ResponseSchema
+	Data models.Listing `json:"data"`
+}
+
+type NotificationPreferencesResponseSchema struct {
+	ResponseSchema
+	Data []models.NotificationPreference `json:"data"`
+}
+
+type AnalyticsResponseSchema struct {
+	ResponseSchema
+	Data []models.ListingAnalytics `json:"data"`
+}
+
+type ImportedListingSchema struct {
+	Row				int				`json:"row" example:"1"`
+	CreateListingResponseDataSchema
+}
+
+type ListingImportErrorSchema struct {
+	Row				int				`json:"row" example:"2"`
+	utils.ErrorResponse
+}
+
+type ImportListingsResponseDataSchema struct {
+	Created			[]ImportedListingSchema			`json:"created"`
+	Errors			[]ListingImportErrorSchema		`json:"errors"`
+}
+
+type ImportListingsResponseSchema struct {
+	ResponseSchema
+	Data			ImportListingsResponseDataSchema	`json:"data"`
+}
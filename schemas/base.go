@@ -10,4 +10,13 @@ func (obj ResponseSchema) Init() ResponseSchema {
 		obj.Status = "success"
 	}
 	return obj
-}
\ No newline at end of file
+}
+
+type SlugMovedResponseDataSchema struct {
+	CanonicalSlug		string		`json:"canonical_slug" example:"new_slug"`
+}
+
+type SlugMovedResponseSchema struct {
+	ResponseSchema
+	Data				SlugMovedResponseDataSchema		`json:"data"`
+}
@@ -0,0 +1,64 @@
+package schemas
+
+import (
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
+)
+
+// REQUEST BODY SCHEMAS
+type StartConversationSchema struct {
+	Listing			string			`json:"listing" validate:"required" example:"listing_slug"`
+	Buyer			*string			`json:"buyer" validate:"omitempty,uuid" example:"2c64c881-59ca-4916-b2bc-8cfb75c3f09b"`
+}
+
+type SendMessageSchema struct {
+	Body			string			`json:"body" validate:"required_without=FileType,max=2000" example:"I can pay by transfer today."`
+	FileType		*string			`json:"file_type" validate:"omitempty,file_type_validator" example:"image/jpeg"`
+}
+
+// QUERY PARAMS SCHEMAS
+type MessagesQuerySchema struct {
+	Page			int				`query:"page" json:"page" validate:"omitempty,gt=0"`
+	Limit			int				`query:"limit" json:"limit" validate:"omitempty,gt=0,lte=100"`
+}
+
+// RESPONSE BODY SCHEMAS
+type ConversationResponseSchema struct {
+	ResponseSchema
+	Data			models.Conversation		`json:"data"`
+}
+
+type ConversationsResponseSchema struct {
+	ResponseSchema
+	Data			[]models.Conversation	`json:"data"`
+}
+
+type MessagesResponseDataSchema struct {
+	Conversation	models.Conversation		`json:"conversation"`
+	Messages		[]models.Message		`json:"messages"`
+}
+
+type MessagesResponseSchema struct {
+	ResponseSchema
+	Data			MessagesResponseDataSchema	`json:"data"`
+	Meta			SearchMetaSchema			`json:"meta"`
+}
+
+type MessageResponseDataSchema struct {
+	models.Message
+	FileUploadData	*utils.SignatureFormat	`json:"file_upload_data"`
+}
+
+type MessageResponseSchema struct {
+	ResponseSchema
+	Data			MessageResponseDataSchema	`json:"data"`
+}
+
+type UnreadMessagesResponseDataSchema struct {
+	UnreadCount		int64			`json:"unread_count" example:"3"`
+}
+
+type UnreadMessagesResponseSchema struct {
+	ResponseSchema
+	Data			UnreadMessagesResponseDataSchema	`json:"data"`
+}
@@ -0,0 +1,23 @@
+package schemas
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+// REQUEST BODY SCHEMAS
+type UpdateExchangeRatesSchema struct {
+	Rates			map[string]decimal.Decimal	`json:"rates" validate:"required,dive,keys,currency_code,endkeys" example:"EUR:0.92,GBP:0.79"`
+}
+
+// RESPONSE BODY SCHEMAS
+type ExchangeRatesResponseDataSchema struct {
+	BaseCurrency	string					`json:"base_currency" example:"USD"`
+	Rates			[]models.ExchangeRate	`json:"rates"`
+}
+
+type ExchangeRatesResponseSchema struct {
+	ResponseSchema
+	Data ExchangeRatesResponseDataSchema `json:"data"`
+}
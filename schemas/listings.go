@@ -12,6 +12,81 @@ type AddOrRemoveWatchlistSchema struct {
 
 type CreateBidSchema struct {
 	Amount					float64			`json:"amount" validate:"required,gt=0" example:"1000.00"`
+	Currency				*string			`json:"currency" validate:"omitempty,currency_code" example:"USD"`
+	Quantity				*int			`json:"quantity" validate:"omitempty,gt=0" example:"1"`
+}
+
+type CreateCategorySchema struct {
+	Name					string			`json:"name" validate:"required,max=50" example:"Phones"`
+	Parent					*string			`json:"parent" example:"parent_category_slug"`
+	DisplayOrder			int				`json:"display_order" example:"0"`
+	Icon					*string			`json:"icon" validate:"omitempty,max=100" example:"fa-solid fa-mobile"`
+}
+
+type UpdateCategorySchema struct {
+	Name					*string			`json:"name" validate:"omitempty,max=50" example:"Phones"`
+	Parent					*string			`json:"parent" example:"parent_category_slug"`
+	DisplayOrder			*int			`json:"display_order" example:"1"`
+	Icon					*string			`json:"icon" validate:"omitempty,max=100" example:"fa-solid fa-mobile"`
+}
+
+type CategoryAttributeSchema struct {
+	Name					string			`json:"name" validate:"required,max=50,attribute_name" example:"mileage"`
+	Label					string			`json:"label" validate:"required,max=100" example:"Mileage (km)"`
+	Type					string			`json:"type" validate:"required,oneof=enum number text bool" example:"number"`
+	Options					[]string		`json:"options" validate:"required_if=Type enum,dive,required,max=50" example:"64GB,128GB"`
+	Required				bool			`json:"required" example:"true"`
+	DisplayOrder			int				`json:"display_order" example:"0"`
+}
+
+type UpdateCategoryAttributesSchema struct {
+	Attributes				[]CategoryAttributeSchema	`json:"attributes" validate:"dive"`
+}
+
+type AskListingQuestionSchema struct {
+	Question				string			`json:"question" validate:"required,max=500" example:"Does it come with a charger?"`
+}
+
+type AnswerListingQuestionSchema struct {
+	Answer					string			`json:"answer" validate:"required,max=1000" example:"Yes, the original one."`
+}
+
+type ModerateListingQuestionSchema struct {
+	Hidden					*bool			`json:"hidden" validate:"required" example:"true"`
+	Reason					*string			`json:"reason" validate:"omitempty,max=500" example:"Contains contact details"`
+}
+
+// QUERY PARAMS SCHEMAS
+type ListingsQuerySchema struct {
+	Cursor					string			`query:"cursor" json:"cursor"`
+	Limit					int				`query:"limit" json:"limit" validate:"omitempty,gt=0,lte=100"`
+	Quantity				int				`query:"quantity" json:"quantity" validate:"omitempty,gt=0,lte=100"`
+	Category				string			`query:"category" json:"category"`
+	MinPrice				float64			`query:"min_price" json:"min_price" validate:"omitempty,gt=0"`
+	MaxPrice				float64			`query:"max_price" json:"max_price" validate:"omitempty,gt=0"`
+	Status					string			`query:"status" json:"status" validate:"omitempty,oneof=upcoming live ended"`
+	State					string			`query:"state" json:"state" validate:"omitempty,oneof=draft pending_review published rejected cancelled ended"`
+	Archived				bool			`query:"archived" json:"archived"`
+	Auctioneer				string			`query:"auctioneer" json:"auctioneer" validate:"omitempty,uuid"`
+	Sort					string			`query:"sort" json:"sort" validate:"omitempty,oneof=newest ending_soon price_asc price_desc most_bids"`
+}
+
+type SearchListingsQuerySchema struct {
+	Q						string			`query:"q" json:"q" validate:"required,max=100"`
+	Status					string			`query:"status" json:"status" validate:"omitempty,oneof=upcoming live ended"`
+	Limit					int				`query:"limit" json:"limit" validate:"omitempty,gt=0,lte=50"`
+	Page					int				`query:"page" json:"page" validate:"omitempty,gt=0"`
+}
+
+type ListingDetailQuerySchema struct {
+	QuestionsPage			int				`query:"questions_page" json:"questions_page" validate:"omitempty,gt=0"`
+	QuestionsLimit			int				`query:"questions_limit" json:"questions_limit" validate:"omitempty,gt=0,lte=50"`
+}
+
+type QuestionsQuerySchema struct {
+	Hidden					bool			`query:"hidden" json:"hidden"`
+	Page					int				`query:"page" json:"page" validate:"omitempty,gt=0"`
+	Limit					int				`query:"limit" json:"limit" validate:"omitempty,gt=0,lte=50"`
 }
 
 // RESPONSE BODY SCHEMAS
@@ -20,9 +95,50 @@ type ListingsResponseSchema struct {
 	Data					[]models.Listing	`json:"data"`
 }
 
+type PaginationMetaSchema struct {
+	NextCursor				*string				`json:"next_cursor" example:"eyJzIjoibmV3ZXN0In0"`
+	Total					int64				`json:"total" example:"42"`
+	Limit					int					`json:"limit" example:"20"`
+}
+
+type PaginatedListingsResponseSchema struct {
+	ResponseSchema
+	Data					[]models.Listing		`json:"data"`
+	Meta					PaginationMetaSchema	`json:"meta"`
+}
+
+type ListingSearchHighlightsSchema struct {
+	Name					string				`json:"name" example:"Apple <mark>iPhone</mark> 14"`
+	Desc					string				`json:"desc" example:"Barely used <mark>iPhone</mark> with box"`
+}
+
+type ListingSearchResultSchema struct {
+	models.Listing
+	Rank					float64							`json:"rank" example:"0.42"`
+	Highlights				ListingSearchHighlightsSchema	`json:"highlights"`
+}
+
+type SearchMetaSchema struct {
+	Total					int64				`json:"total" example:"42"`
+	Page					int					`json:"page" example:"1"`
+	Limit					int					`json:"limit" example:"20"`
+}
+
+type ListingSearchResponseSchema struct {
+	ResponseSchema
+	Data					[]ListingSearchResultSchema	`json:"data"`
+	Meta					SearchMetaSchema			`json:"meta"`
+}
+
+type ListingQuestionsSchema struct {
+	Items					[]models.ListingQuestion	`json:"items"`
+	Meta					SearchMetaSchema			`json:"meta"`
+}
+
 type ListingDetailResponseDataSchema struct {
-	Listing					models.Listing		`json:"listing"`
-	RelatedListings			[]models.Listing	`json:"related_listings"`
+	Listing					models.Listing			`json:"listing"`
+	RelatedListings			[]models.Listing		`json:"related_listings"`
+	Questions				ListingQuestionsSchema	`json:"questions"`
 }
 
 type ListingDetailResponseSchema struct {
@@ -32,6 +148,7 @@ type ListingDetailResponseSchema struct {
 
 type AddOrRemoveWatchlistResponseDataSchema struct {
 	GuestUserId				*uuid.UUID						`json:"guestuser_id"`
+	CanonicalSlug			string							`json:"canonical_slug" example:"listing_slug"`
 }
 
 type AddOrRemoveWatchlistResponseSchema struct {
@@ -39,6 +156,16 @@ type AddOrRemoveWatchlistResponseSchema struct {
 	Data					AddOrRemoveWatchlistResponseDataSchema		`json:"data"`
 }
 
+type CategoryResponseSchema struct {
+	ResponseSchema
+	Data					models.Category		`json:"data"`
+}
+
+type CategoryAttributesResponseSchema struct {
+	ResponseSchema
+	Data					[]models.CategoryAttribute	`json:"data"`
+}
+
 type CategoriesResponseSchema struct {
 	ResponseSchema
 	Data					[]models.Category	`json:"data"`
@@ -54,6 +181,32 @@ type BidsResponseSchema struct {
 	Data					BidResponseDataSchema		`json:"data"`
 }
 
+type ListingRevisionsResponseDataSchema struct {
+	Listing					string						`json:"listing"`
+	Revisions				[]models.ListingRevision	`json:"revisions"`
+}
+
+type ListingRevisionsResponseSchema struct {
+	ResponseSchema
+	Data					ListingRevisionsResponseDataSchema		`json:"data"`
+}
+
+type ListingQuestionResponseSchema struct {
+	ResponseSchema
+	Data					models.ListingQuestion		`json:"data"`
+}
+
+type ListingQuestionsResponseSchema struct {
+	ResponseSchema
+	Data					[]models.ListingQuestion	`json:"data"`
+	Meta					SearchMetaSchema			`json:"meta"`
+}
+
+type AuctionResultResponseSchema struct {
+	ResponseSchema
+	Data					models.AuctionResult		`json:"data"`
+}
+
 type BidResponseSchema struct {
 	ResponseSchema
 	Data					models.Bid			`json:"data"`			
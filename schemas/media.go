@@ -0,0 +1,19 @@
+package schemas
+
+import (
+	"github.com/satori/go.uuid"
+)
+
+// RESPONSE BODY SCHEMAS
+type FileResponseDataSchema struct {
+	ID						uuid.UUID			`json:"id" example:"f47ac10b-58cc-4372-a567-0e02b2c3d479"`
+	ResourceType			string				`json:"resource_type" example:"image/jpeg"`
+	Status					string				`json:"status" example:"ready"`
+	Size					int64				`json:"size" example:"204800"`
+	Url						string				`json:"url" example:"https://res.cloudinary.com/demo/image/upload/bidout-auction-v7/listings/f47ac10b-58cc-4372-a567-0e02b2c3d479.jpg"`
+}
+
+type FileResponseSchema struct {
+	ResponseSchema
+	Data					FileResponseDataSchema	`json:"data"`
+}
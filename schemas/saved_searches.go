@@ -0,0 +1,35 @@
+package schemas
+
+import (
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+// REQUEST BODY SCHEMAS
+type CreateSavedSearchSchema struct {
+	Name			string			`json:"name" validate:"required,max=100" example:"Cheap phones"`
+	Query			string			`json:"query" validate:"required,max=100" example:"iphone"`
+	Category		*string			`json:"category" example:"category_slug"`
+	MinPrice		*float64		`json:"min_price" validate:"omitempty,gt=0" example:"100.00"`
+	MaxPrice		*float64		`json:"max_price" validate:"omitempty,gt=0" example:"1000.00"`
+	Frequency		string			`json:"frequency" validate:"omitempty,oneof=instant digest" example:"instant"`
+}
+
+type UpdateSavedSearchSchema struct {
+	Name			*string			`json:"name" validate:"omitempty,max=100" example:"Cheap phones"`
+	Query			*string			`json:"query" validate:"omitempty,max=100" example:"iphone"`
+	Category		*string			`json:"category" example:"category_slug"`
+	MinPrice		*float64		`json:"min_price" validate:"omitempty,gt=0" example:"100.00"`
+	MaxPrice		*float64		`json:"max_price" validate:"omitempty,gt=0" example:"1000.00"`
+	Frequency		*string			`json:"frequency" validate:"omitempty,oneof=instant digest" example:"digest"`
+}
+
+// RESPONSE BODY SCHEMAS
+type SavedSearchesResponseSchema struct {
+	ResponseSchema
+	Data []models.SavedSearch `json:"data"`
+}
+
+type SavedSearchResponseSchema struct {
+	ResponseSchema
+	Data models.SavedSearch `json:"data"`
+}
@@ -0,0 +1,29 @@
+package schemas
+
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+)
+
+// REQUEST BODY SCHEMAS
+type WalletAmountSchema struct {
+	Amount			float64			`json:"amount" validate:"required,gt=0" example:"1000.00"`
+}
+
+// RESPONSE BODY SCHEMAS
+type WalletResponseDataSchema struct {
+	Available		decimal.Decimal				`json:"available" example:"1000.00"`
+	Held			decimal.Decimal				`json:"held" example:"500.00"`
+	Transactions	[]models.LedgerTransaction	`json:"transactions"`
+}
+
+type WalletResponseSchema struct {
+	ResponseSchema
+	Data WalletResponseDataSchema `json:"data"`
+}
+
+type WalletTransactionResponseSchema struct {
+	ResponseSchema
+	Data models.LedgerTransaction `json:"data"`
+}
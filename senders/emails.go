@@ -60,8 +60,6 @@ type EmailContext struct {
 func SendEmail(env interface{}, db *gorm.DB, user models.User, emailType string) {
 	env = env.(string)
 	if env == "normal" {
-		cfg := config.GetConfig()
-
 		emailData := sortEmail(db, user, emailType)
 		templateFile := emailData["template_file"]
 		subject := emailData["subject"]
@@ -74,45 +72,58 @@ func SendEmail(env interface{}, db *gorm.DB, user models.User, emailType string)
 			code := otp.(*int)
 			data.Otp = code
 		}
+		bodyContent := renderTemplate(templateFile.(string), data)
 
-		// Read the HTML file content
-		_, file, _, ok := runtime.Caller(0)
-		if !ok {
-			log.Println("Unable to identify current directory (needed to load templates)", os.Stderr)
-			os.Exit(1)
-		}
-		basepath := filepath.Dir(file)
-		tempfile := fmt.Sprintf("../%s", templateFile.(string))
-		htmlContent, err := os.ReadFile(filepath.Join(basepath, tempfile))
-		if err != nil {
-			log.Fatal("Error reading HTML file:", err)
+		// Send the email
+		if err := deliver(user.Email, subject.(string), bodyContent); err != nil {
+			log.Fatal("Error sending email:", err)
 		}
+	}
+}
 
-		// Create a new template from the HTML file content
-		tmpl, err := template.New("email_template").Parse(string(htmlContent))
-		if err != nil {
-			log.Fatal("Error parsing template:", err)
-		}
+// Executes an email template with the given context
+func renderTemplate(templateFile string, data interface{}) string {
+	// Read the HTML file content
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		log.Println("Unable to identify current directory (needed to load templates)", os.Stderr)
+		os.Exit(1)
+	}
+	basepath := filepath.Dir(file)
+	tempfile := fmt.Sprintf("../%s", templateFile)
+	htmlContent, err := os.ReadFile(filepath.Join(basepath, tempfile))
+	if err != nil {
+		log.Fatal("Error reading HTML file:", err)
+	}
 
-		// Execute the template with the context and set it as the body of the email
-		var bodyContent bytes.Buffer
-		if err := tmpl.Execute(&bodyContent, data); err != nil {
-			log.Fatal("Error executing template:", err)
-		}
+	// Create a new template from the HTML file content
+	tmpl, err := template.New("email_template").Parse(string(htmlContent))
+	if err != nil {
+		log.Fatal("Error parsing template:", err)
+	}
 
-		// Create a new message
-		m := gomail.NewMessage()
-		m.SetHeader("From", cfg.MailSenderEmail)
-		m.SetHeader("To", user.Email)
-		m.SetHeader("Subject", subject.(string))
-		m.SetBody("text/html", bodyContent.String())
+	// Execute the template with the context and set it as the body of the email
+	var bodyContent bytes.Buffer
+	if err := tmpl.Execute(&bodyContent, data); err != nil {
+		log.Fatal("Error executing template:", err)
+	}
+	return bodyContent.String()
+}
 
-		// Create a new SMTP client
-		d := gomail.NewDialer(cfg.MailSenderHost, cfg.MailSenderPort, cfg.MailSenderEmail, cfg.MailSenderPassword)
+// Sends an html email
+func deliver(to string, subject string, body string) error {
+	cfg := config.GetConfig()
 
-		// Send the email
-		if err := d.DialAndSend(m); err != nil {
-			log.Fatal("Error sending email:", err)
-		}
-	}
+	// Create a new message
+	m := gomail.NewMessage()
+	m.SetHeader("From", cfg.MailSenderEmail)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
+	// Create a new SMTP client
+	d := gomail.NewDialer(cfg.MailSenderHost, cfg.MailSenderPort, cfg.MailSenderEmail, cfg.MailSenderPassword)
+
+	// Send the email
+	return d.DialAndSend(m)
 }
@@ -0,0 +1,214 @@
+package senders
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kayprogrammer/bidout-auction-v7/config"
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationContext struct {
+	Name			string
+	Message			string
+	Link			string
+	LinkText		string
+	UnsubscribeLink	string
+}
+
+// Returns the frontend link of a listing
+func listingLink(listing models.Listing) string {
+	slug := ""
+	if listing.Slug != nil {
+		slug = *listing.Slug
+	}
+	return fmt.Sprintf("%s/listings/%s", config.GetConfig().FrontendURL, slug)
+}
+
+// Emails a user about an auction event. Nothing is sent if the user opted out of
+// that kind of notification or was already notified with the same key
+func Notify(env interface{}, db *gorm.DB, user models.User, kind string, key string, subject string, data NotificationContext) {
+	if !models.NotificationEnabled(db, user.ID, kind) {
+		return
+	}
+
+	// The unique (user, kind, key) index makes this a no-op for duplicates
+	notification := models.Notification{UserId: user.ID, Kind: kind, Key: key}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	if env.(string) == "normal" {
+		data.Name = user.FirstName
+		bodyContent := renderTemplate("templates/notification.html", data)
+		if err := deliver(user.Email, subject, bodyContent); err != nil {
+			log.Println("Error sending notification:", err)
+		}
+	}
+}
+
+// Tells a bidder that their bid is no longer winning
+func NotifyOutbid(env interface{}, db *gorm.DB, bid models.Bid, listing models.Listing) {
+	user := models.User{}
+	db.Take(&user, bid.UserId)
+	data := NotificationContext{
+		Message:  fmt.Sprintf("Your bid of %s on %s has been outbid. Place a new bid before the auction closes.", bid.Amount.StringFixed(2), listing.Name),
+		Link:     listingLink(listing),
+		LinkText: "View listing",
+	}
+	// One email per bid, however many times it gets outbid
+	Notify(env, db, user, models.NotificationOutbid, bid.ID.String(), "You have been outbid", data)
+}
+
+// Reminds a watcher that a listing closes soon
+func NotifyEndingSoon(env interface{}, db *gorm.DB, user models.User, listing models.Listing) {
+	data := NotificationContext{
+		Message:  fmt.Sprintf("%s, which is on your watchlist, closes in less than an hour.", listing.Name),
+		Link:     listingLink(listing),
+		LinkText: "Place your bid",
+	}
+	Notify(env, db, user, models.NotificationEndingSoon, listing.ID.String(), "An auction on your watchlist is ending soon", data)
+}
+
+// Tells a watcher that bidding has opened on a scheduled listing
+func NotifyAuctionLive(env interface{}, db *gorm.DB, user models.User, listing models.Listing) {
+	data := NotificationContext{
+		Message:  fmt.Sprintf("%s, which is on your watchlist, is now open for bidding.", listing.Name),
+		Link:     listingLink(listing),
+		LinkText: "Place your bid",
+	}
+	Notify(env, db, user, models.NotificationAuctionLive, listing.ID.String(), "An auction on your watchlist is live", data)
+}
+
+// Returns the link that asks to confirm deleting a saved search without logging in
+func unsubscribeLink(search models.SavedSearch) string {
+	return fmt.Sprintf("%s/saved-searches/unsubscribe/%s", config.GetConfig().FrontendURL, search.UnsubscribeToken)
+}
+
+// Tells the owner of a saved search about a new listing that matches it
+func NotifySavedSearchMatch(env interface{}, db *gorm.DB, search models.SavedSearch, listing models.Listing) {
+	data := NotificationContext{
+		Message:         fmt.Sprintf("%s was just listed and matches your saved search \"%s\".", listing.Name, search.Name),
+		Link:            listingLink(listing),
+		LinkText:        "View listing",
+		UnsubscribeLink: unsubscribeLink(search),
+	}
+	key := fmt.Sprintf("%s:%s", search.ID, listing.ID)
+	Notify(env, db, search.User, models.NotificationSavedSearch, key, "New listing for your saved search", data)
+}
+
+// Sends a single email with every new listing that matched a saved search since the last digest
+func NotifySavedSearchDigest(env interface{}, db *gorm.DB, search models.SavedSearch, listings []models.Listing) {
+	names := []string{}
+	for _, listing := range listings {
+		names = append(names, listing.Name)
+	}
+	data := NotificationContext{
+		Message:         fmt.Sprintf("%d new listings match your saved search \"%s\": %s.", len(listings), search.Name, strings.Join(names, ", ")),
+		Link:            fmt.Sprintf("%s/listings/search?q=%s", config.GetConfig().FrontendURL, url.QueryEscape(search.Query)),
+		LinkText:        "View listings",
+		UnsubscribeLink: unsubscribeLink(search),
+	}
+	key := fmt.Sprintf("%s:digest:%s", search.ID, time.Now().UTC().Format("2006-01-02"))
+	Notify(env, db, search.User, models.NotificationSavedSearch, key, "New listings for your saved search", data)
+}
+
+// Alerts users with instant saved searches that a new listing matches
+func AlertSavedSearches(env interface{}, db *gorm.DB, listing models.Listing) {
+	for _, search := range models.MatchingSavedSearches(db, listing.ID, models.AlertInstant) {
+		NotifySavedSearchMatch(env, db, search, listing)
+	}
+}
+
+// Tells a seller that staff approved or rejected their listing
+func NotifyModerationOutcome(env interface{}, db *gorm.DB, listing models.Listing) {
+	user := models.User{}
+	db.Take(&user, listing.AuctioneerId)
+	data := NotificationContext{Link: listingLink(listing), LinkText: "View listing"}
+	subject := "Your listing has been approved"
+	if listing.State == models.ListingStateRejected {
+		subject = "Your listing has been rejected"
+		reason := ""
+		if listing.RejectionReason != nil {
+			reason = *listing.RejectionReason
+		}
+		data.Message = fmt.Sprintf("%s was rejected for the following reason: %s. Update it and submit it for review again.", listing.Name, reason)
+		data.Link = fmt.Sprintf("%s/auctioneer/listings/%s", config.GetConfig().FrontendURL, *listing.Slug)
+		data.LinkText = "Edit listing"
+	} else {
+		data.Message = fmt.Sprintf("%s has been approved and is now visible to bidders.", listing.Name)
+	}
+	// Listings can be reviewed several times, once per submission
+	key := fmt.Sprintf("%s:%s:%d", listing.ID, listing.State, listing.UpdatedAt.UnixNano())
+	Notify(env, db, user, models.NotificationModeration, key, subject, data)
+}
+
+// Tells the bidders and watchers of a listing that the seller cancelled it
+func NotifyListingCancelled(env interface{}, db *gorm.DB, listing models.Listing) {
+	bidderIds := db.Model(&models.Bid{}).Select("user_id").Where("listing_id = ?", listing.ID)
+	watcherIds := db.Model(&models.Watchlist{}).Select("user_id").Where("listing_id = ? AND user_id IS NOT NULL", listing.ID)
+	users := []models.User{}
+	db.Where("id != ? AND (id IN (?) OR id IN (?))", listing.AuctioneerId, bidderIds, watcherIds).Find(&users)
+
+	reason := ""
+	if listing.CancellationReason != nil {
+		reason = *listing.CancellationReason
+	}
+	data := NotificationContext{
+		Message:  fmt.Sprintf("%s has been cancelled by the seller: %s. Any deposit held for your bid has been released.", listing.Name, reason),
+		Link:     fmt.Sprintf("%s/listings", config.GetConfig().FrontendURL),
+		LinkText: "Browse listings",
+	}
+	for _, user := range users {
+		Notify(env, db, user, models.NotificationCancelled, listing.ID.String(), "An auction you're following was cancelled", data)
+	}
+}
+
+// Tells the bidders of a listing what the seller changed on it
+func NotifyListingUpdated(env interface{}, db *gorm.DB, listing models.Listing, changes []string) {
+	bidderIds := db.Model(&models.Bid{}).Select("user_id").Where("listing_id = ?", listing.ID)
+	users := []models.User{}
+	db.Where("id != ? AND id IN (?)", listing.AuctioneerId, bidderIds).Find(&users)
+
+	data := NotificationContext{
+		Message:  fmt.Sprintf("The seller of %s, which you've bid on, updated its %s. Check the listing to make sure you're still happy with your bid.", listing.Name, strings.Join(changes, ", ")),
+		Link:     listingLink(listing),
+		LinkText: "View listing",
+	}
+	// One email per edit
+	key := fmt.Sprintf("%s:%d", listing.ID, listing.UpdatedAt.UnixNano())
+	for _, user := range users {
+		Notify(env, db, user, models.NotificationListingUpdated, key, "An auction you've bid on was updated", data)
+	}
+}
+
+// Tells a seller that someone asked a question on their listing
+func NotifyQuestionAsked(env interface{}, db *gorm.DB, question models.ListingQuestion, listing models.Listing) {
+	user := models.User{}
+	db.Take(&user, listing.AuctioneerId)
+	data := NotificationContext{
+		Message:  fmt.Sprintf("Someone asked a question about %s: \"%s\". Your answer will be shown on the listing.", listing.Name, question.Question),
+		Link:     listingLink(listing),
+		LinkText: "Answer question",
+	}
+	Notify(env, db, user, models.NotificationQuestionAsked, question.ID.String(), "New question on your listing", data)
+}
+
+// Tells a user that the seller answered their question
+func NotifyQuestionAnswered(env interface{}, db *gorm.DB, question models.ListingQuestion, listing models.Listing) {
+	user := models.User{}
+	db.Take(&user, question.UserId)
+	data := NotificationContext{
+		Message:  fmt.Sprintf("The seller of %s answered your question \"%s\": %s", listing.Name, question.Question, *question.Answer),
+		Link:     listingLink(listing),
+		LinkText: "View listing",
+	}
+	// Only the first answer is emailed, edits to it aren't
+	Notify(env, db, user, models.NotificationQuestionAnswered, question.ID.String(), "Your question was answered", data)
+}
@@ -4,14 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 	"github.com/shopspring/decimal"
 	
+	"github.com/kayprogrammer/bidout-auction-v7/analytics"
+	"github.com/kayprogrammer/bidout-auction-v7/auctions"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
 	"github.com/kayprogrammer/bidout-auction-v7/schemas"
 )
 
@@ -125,6 +130,128 @@ func createListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 		expectedData := make(map[string]interface{})
 		expectedData["category"] = "Invalid category!"
 		assert.Equal(t, expectedData, body["data"].(map[string]interface{}))
+
+		// Verify that create listing failed with an unsupported currency
+		createListingData.Category = *category.Slug
+		createListingData.Currency = "XYZ"
+		res = ProcessTestBody(t, app, url, "POST", createListingData, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, map[string]interface{}{"currency": "Unsupported currency!"}, body["data"].(map[string]interface{}))
+	})
+}
+
+func createListingWithAttributes(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	category := models.Category{Name: "Cars"}
+	db.Create(&category)
+	models.SetCategoryAttributes(db, category.ID, []models.CategoryAttribute{
+		{Name: "year", Label: "Year", Type: models.AttributeNumber, Required: true},
+		{Name: "fuel", Label: "Fuel", Type: models.AttributeEnum, Options: []string{"petrol", "diesel"}},
+	})
+
+	t.Run("Create Listing With Attributes", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings", baseUrl)
+		createListingData := schemas.CreateListingSchema{
+			Name:        "Test Car",
+			Desc:        "Test description",
+			Category:    *category.Slug,
+			Price:       1000.00,
+			ClosingDate: "2250-01-02T15:04:05.000Z",
+			FileType:    "image/jpeg",
+			Attributes:  map[string]interface{}{"fuel": "steam", "colour": "red"},
+		}
+
+		// Verify that invalid and missing attributes fail
+		res := ProcessTestBody(t, app, url, "POST", createListingData, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, map[string]interface{}{
+			"attributes.year":   "This field is required.",
+			"attributes.fuel":   "Must be one of: petrol diesel",
+			"attributes.colour": "Unknown attribute!",
+		}, body["data"])
+
+		// Verify that valid attributes are stored with the listing
+		createListingData.Attributes = map[string]interface{}{"year": 2019, "fuel": "diesel"}
+		res = ProcessTestBody(t, app, url, "POST", createListingData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"year": float64(2019), "fuel": "diesel"}, body["data"].(map[string]interface{})["attributes"])
+
+		// Verify that listings can be filtered by attributes
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/listings?attr.year.min=2015&attr.fuel=diesel", baseUrl), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, 1, len(body["data"].([]interface{})))
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("%s/listings?attr.year.min=2020", baseUrl), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, 0, len(body["data"].([]interface{})))
+	})
+}
+
+func importListings(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	t.Run("Import Listings", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings/import", baseUrl)
+		validListing := schemas.CreateListingSchema{
+			Name:        "Imported Listing",
+			Desc:        "Imported description",
+			Category:    "other",
+			Price:       1000.00,
+			ClosingDate: "2250-01-02T15:04:05.000Z",
+			FileType:    "image/jpeg",
+		}
+		invalidListing := validListing
+		invalidListing.Category = "invalid-category"
+		importData := schemas.ImportListingsSchema{Listings: []schemas.CreateListingSchema{validListing, invalidListing}}
+
+		// Verify that nothing is imported when a row is invalid
+		res := ProcessTestBody(t, app, url, "POST", importData, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, 0, len(data["created"].([]interface{})))
+		importErrors := data["errors"].([]interface{})
+		assert.Equal(t, 1, len(importErrors))
+		importError := importErrors[0].(map[string]interface{})
+		assert.Equal(t, float64(2), importError["row"])
+		assert.Equal(t, map[string]interface{}{"category": "Invalid category!"}, importError["data"])
+
+		// Verify that the valid rows are imported in partial mode
+		res = ProcessTestBody(t, app, url+"?mode=partial", "POST", importData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		data = body["data"].(map[string]interface{})
+		created := data["created"].([]interface{})
+		assert.Equal(t, 1, len(created))
+		assert.NotNil(t, created[0].(map[string]interface{})["file_upload_data"])
+		assert.Equal(t, 1, len(data["errors"].([]interface{})))
+
+		// Verify that listings are imported from a CSV file
+		csvContent := "name,desc,category,price,closing_date,file_type\n" +
+			"CSV Listing,CSV description,other,500.50,2250-01-02T15:04:05.000Z,image/png\n" +
+			"Bad Price,CSV description,other,cheap,2250-01-02T15:04:05.000Z,image/png\n"
+		req := httptest.NewRequest("POST", url+"?mode=partial", strings.NewReader(csvContent))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		data = body["data"].(map[string]interface{})
+		assert.Equal(t, 1, len(data["created"].([]interface{})))
+		importError = data["errors"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"price": "Must be a number!"}, importError["data"])
 	})
 }
 
@@ -172,6 +299,51 @@ func updateListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 	})
 }
 
+func updateListingBuyNow(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	listing := CreateListing(db)
+	sealedListing := CreateSealedListing(db)
+
+	t.Run("Update Listing Buy Now", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings/%s", baseUrl, *listing.Slug)
+
+		// Verify that a buy now price can be set
+		buyNowPrice := 5000.00
+		res := ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{BuyNowPrice: &buyNowPrice}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "5000", data["buy_now_price"])
+		assert.Equal(t, true, data["buy_now_available"])
+
+		// Verify that it can't be set and removed at once
+		removeBuyNow := true
+		res = ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{BuyNowPrice: &buyNowPrice, RemoveBuyNow: &removeBuyNow}, access)
+		assert.Equal(t, 422, res.StatusCode)
+
+		// Verify that the buy now price can be removed
+		res = ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{RemoveBuyNow: &removeBuyNow}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		data = body["data"].(map[string]interface{})
+		assert.Nil(t, data["buy_now_price"])
+		assert.Equal(t, false, data["buy_now_available"])
+
+		// Verify that sealed listings can't offer buy now, so their hidden bids can't be inferred from it
+		url = fmt.Sprintf("%s/listings/%s", baseUrl, *sealedListing.Slug)
+		res = ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{BuyNowPrice: &buyNowPrice}, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Buy now is not available for this auction type!", body["data"].(map[string]interface{})["buy_now_price"])
+
+		sealedBuyNowPrice := decimal.NewFromInt(5000)
+		sealedListing.BuyNowPrice = &sealedBuyNowPrice
+		db.Save(&sealedListing)
+		assert.Equal(t, false, sealedListing.Init(db).BuyNowAvailable)
+	})
+}
+
 func getAuctioneerListingBids(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 	user := CreateTestVerifiedUser(db)
 	access := CreateJwt(db, user.ID).Access
@@ -219,6 +391,302 @@ func getAuctioneerListingBids(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl
 	})
 }
 
+func updateListingWithBids(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	listing := CreateListing(db)
+	bidder := CreateAnotherTestVerifiedUser(db)
+	db.Create(&models.Bid{UserId: bidder.ID, ListingId: listing.ID, Amount: decimal.NewFromFloat(2000.00)})
+
+	t.Run("Update Listing With Bids", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings/%s", baseUrl, *listing.Slug)
+
+		// Verify that the price and closing date are locked
+		price := 500.00
+		closingDate := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+		res := ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{Price: &price, ClosingDate: &closingDate}, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "This can't be changed once the listing has bids!", data["price"])
+		assert.Equal(t, "This can't be changed once the listing has bids!", data["closing_date"])
+		db.Take(&listing, listing.ID)
+		assert.Equal(t, true, listing.Price.Equal(decimal.NewFromInt(1000)))
+
+		// Verify that the listing can't be deactivated
+		active := false
+		res = ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{Active: &active}, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Listings with bids can't be deactivated. Cancel the listing instead!", body["data"].(map[string]interface{})["active"])
+
+		// Verify that name and description edits are allowed and recorded
+		name := "Renamed Listing"
+		desc := "Updated description"
+		res = ProcessTestBody(t, app, url, "PATCH", schemas.UpdateListingSchema{Name: &name, Desc: &desc}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		slug := body["data"].(map[string]interface{})["slug"].(string)
+
+		res, _ = app.Test(httptest.NewRequest("GET", fmt.Sprintf("/api/v7/listings/detail/%s/revisions", slug), nil))
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		revisions := body["data"].(map[string]interface{})["revisions"].([]interface{})
+		assert.Equal(t, 2, len(revisions))
+		fields := []interface{}{}
+		for _, revision := range revisions {
+			fields = append(fields, revision.(map[string]interface{})["field"])
+		}
+		assert.Contains(t, fields, "name")
+		assert.Contains(t, fields, "desc")
+	})
+}
+
+func cancelAndDeleteListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	listing := CreateListing(db)
+	bidder := CreateAnotherTestVerifiedUser(db)
+	db.Create(&models.Bid{UserId: bidder.ID, ListingId: listing.ID, Amount: decimal.NewFromFloat(2000.00)})
+
+	t.Run("Cancel And Delete Listing", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings/%s", baseUrl, *listing.Slug)
+
+		// Verify that published listings must be cancelled before deletion
+		req := httptest.NewRequest("DELETE", url, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ := app.Test(req)
+		assert.Equal(t, 400, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Cancel this listing before deleting it!", body["message"])
+
+		// Verify that a cancellation needs a reason
+		res = ProcessTestBody(t, app, url+"/cancel", "POST", schemas.CancelListingSchema{}, access)
+		assert.Equal(t, 422, res.StatusCode)
+
+		// Verify that the listing is cancelled and hidden from the public
+		res = ProcessTestBody(t, app, url+"/cancel", "POST", schemas.CancelListingSchema{Reason: "Sold elsewhere"}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Listing cancelled", body["message"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, models.ListingStateCancelled, data["state"])
+		assert.Equal(t, "Sold elsewhere", data["cancellation_reason"])
+		res, _ = app.Test(httptest.NewRequest("GET", fmt.Sprintf("/api/v7/listings/detail/%s", *listing.Slug), nil))
+		assert.Equal(t, 404, res.StatusCode)
+
+		// Verify that the listing is archived with its bids
+		req = httptest.NewRequest("DELETE", url, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		var bidsCount int64
+		db.Model(&models.Bid{}).Where("listing_id = ?", listing.ID).Count(&bidsCount)
+		assert.Equal(t, int64(1), bidsCount)
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("%s/listings?archived=true", baseUrl), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		listings := body["data"].([]interface{})
+		assert.Equal(t, 1, len(listings))
+		assert.Equal(t, true, listings[0].(map[string]interface{})["archived"])
+	})
+}
+
+func relistListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	listing := CreateListing(db)
+
+	t.Run("Relist Listing", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings/%s/relist", baseUrl, *listing.Slug)
+		relistData := schemas.RelistListingSchema{ClosingDate: "2250-01-02T15:04:05.000Z"}
+
+		// Verify that listings can't be relisted before they end
+		res := ProcessTestBody(t, app, url, "POST", relistData, access)
+		assert.Equal(t, 400, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Only ended listings can be relisted!", body["message"])
+
+		// Verify that auto relisting can be turned on and off after creation
+		autoRelistTimes := 2
+		updateUrl := fmt.Sprintf("%s/listings/%s", baseUrl, *listing.Slug)
+		res = ProcessTestBody(t, app, updateUrl, "PATCH", schemas.UpdateListingSchema{AutoRelist: &autoRelistTimes}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, float64(2), body["data"].(map[string]interface{})["auto_relist"])
+		autoRelistTimes = 11
+		res = ProcessTestBody(t, app, updateUrl, "PATCH", schemas.UpdateListingSchema{AutoRelist: &autoRelistTimes}, access)
+		assert.Equal(t, 422, res.StatusCode)
+		autoRelistTimes = 0
+		res = ProcessTestBody(t, app, updateUrl, "PATCH", schemas.UpdateListingSchema{AutoRelist: &autoRelistTimes}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, float64(0), body["data"].(map[string]interface{})["auto_relist"])
+
+		// Verify that a listing ending without a winner relists itself when asked to
+		events, unsubscribe := realtime.Subscribe(listing.ID)
+		defer unsubscribe()
+		db.Model(&listing).UpdateColumns(map[string]interface{}{"auto_relist": 1, "closing_date": time.Now().Add(-time.Minute)})
+		auctions.Finalize(db, listing.ID)
+		autoRelist := models.Listing{}
+		db.Take(&autoRelist, "relisted_from_id = ?", listing.ID)
+		assert.Equal(t, models.ListingStatePublished, autoRelist.State)
+		assert.Equal(t, 1, autoRelist.RelistCount)
+		assert.True(t, autoRelist.ClosingDate.After(time.Now()))
+		assert.Equal(t, realtime.ListingClosed, (<-events).Type)
+		assert.Equal(t, realtime.ListingRelisted, (<-events).Type)
+
+		// Verify that a listing that was relisted already can't be relisted again
+		res = ProcessTestBody(t, app, url, "POST", relistData, access)
+		assert.Equal(t, 400, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "This listing has been relisted already!", body["message"])
+
+		// Verify that an ended listing is copied into a draft at a new price
+		endedListing := CreateListing(db)
+		db.Model(&endedListing).UpdateColumns(map[string]interface{}{"closing_date": time.Now().Add(-time.Minute)})
+		auctions.Finalize(db, endedListing.ID)
+		url = fmt.Sprintf("%s/listings/%s/relist", baseUrl, *endedListing.Slug)
+		price := 800.00
+		relistData.Price = &price
+		res = ProcessTestBody(t, app, url, "POST", relistData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Listing relisted successfully", body["message"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, models.ListingStateDraft, data["state"])
+		assert.Equal(t, listing.Name, data["name"])
+		assert.Equal(t, "800", data["price"])
+		assert.Equal(t, 1, len(data["images"].([]interface{})))
+
+		// Verify that the draft counts as the listing's relist
+		res = ProcessTestBody(t, app, url, "POST", relistData, access)
+		assert.Equal(t, 400, res.StatusCode)
+	})
+}
+
+func manageListingImages(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	listing := CreateListing(db)
+
+	t.Run("Manage Listing Images", func(t *testing.T) {
+		url := fmt.Sprintf("%s/listings/%s/images", baseUrl, *listing.Slug)
+
+		// Verify that images are added after the cover image
+		imagesData := schemas.AddListingImagesSchema{FileTypes: []string{"image/png", "image/jpeg"}}
+		res := ProcessTestBody(t, app, url, "POST", imagesData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Images added successfully", body["message"])
+		images := body["data"].([]interface{})
+		assert.Equal(t, 3, len(images))
+		assert.Equal(t, true, images[0].(map[string]interface{})["is_cover"])
+		assert.Contains(t, images[1].(map[string]interface{}), "file_upload_data")
+
+		// Verify that the last image becomes the cover after reordering
+		imageIds := []string{}
+		for i := len(images) - 1; i >= 0; i-- {
+			imageIds = append(imageIds, images[i].(map[string]interface{})["id"].(string))
+		}
+		res = ProcessTestBody(t, app, url, "PUT", schemas.ReorderListingImagesSchema{ImageIds: imageIds}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Images reordered successfully", body["message"])
+		images = body["data"].([]interface{})
+		assert.Equal(t, imageIds[0], images[0].(map[string]interface{})["id"])
+		assert.Equal(t, true, images[0].(map[string]interface{})["is_cover"])
+
+		// Verify that an incomplete order fails
+		res = ProcessTestBody(t, app, url, "PUT", schemas.ReorderListingImagesSchema{ImageIds: imageIds[:1]}, access)
+		assert.Equal(t, 422, res.StatusCode)
+
+		// Verify that removing the cover image moves the cover to the next image
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("%s/%s", url, imageIds[0]), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Image removed successfully", body["message"])
+		images = body["data"].([]interface{})
+		assert.Equal(t, 2, len(images))
+		assert.Equal(t, imageIds[1], images[0].(map[string]interface{})["id"])
+		assert.Equal(t, true, images[0].(map[string]interface{})["is_cover"])
+
+		// Verify that the gallery can't grow past the limit
+		fileTypes := []string{}
+		for i := len(images); i <= models.MaxListingImages; i++ {
+			fileTypes = append(fileTypes, "image/png")
+		}
+		res = ProcessTestBody(t, app, url, "POST", schemas.AddListingImagesSchema{FileTypes: fileTypes}, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, fmt.Sprintf("A listing can have at most %d images!", models.MaxListingImages), body["data"].(map[string]interface{})["file_types"])
+		var imagesCount int64
+		db.Model(&models.ListingImage{}).Where("listing_id = ?", listing.ID).Count(&imagesCount)
+		assert.Equal(t, int64(2), imagesCount)
+	})
+}
+
+func getAuctioneerAnalytics(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	listing := CreateListing(db)
+
+	t.Run("Get Auctioneer Analytics", func(t *testing.T) {
+		// View the listing twice as the same visitor, then flush the counters
+		detailUrl := fmt.Sprintf("/api/v7/listings/detail/%s", *listing.Slug)
+		app.Test(httptest.NewRequest("GET", detailUrl, nil))
+		app.Test(httptest.NewRequest("GET", detailUrl, nil))
+		analytics.Flush(db)
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/analytics?days=7&listing=%s", baseUrl, *listing.Slug), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ := app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Analytics fetched", body["message"])
+		data := body["data"].([]interface{})
+		assert.Equal(t, 1, len(data))
+		listingAnalytics := data[0].(map[string]interface{})
+		assert.Equal(t, float64(2), listingAnalytics["views"])
+		assert.Equal(t, float64(1), listingAnalytics["unique_viewers"])
+		assert.Equal(t, 7, len(listingAnalytics["days"].([]interface{})))
+	})
+}
+
+func updateNotificationPreferences(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	t.Run("Update Notification Preferences", func(t *testing.T) {
+		url := fmt.Sprintf("%s/notifications", baseUrl)
+
+		// Verify that the request fails with an invalid kind
+		preferencesData := schemas.UpdateNotificationPreferencesSchema{
+			Preferences: map[string]bool{"invalid_kind": false},
+		}
+		res := ProcessTestBody(t, app, url, "PUT", preferencesData, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Invalid Entry", body["message"])
+
+		// Verify that the user opted out successfully
+		preferencesData.Preferences = map[string]bool{models.NotificationOutbid: false}
+		res = ProcessTestBody(t, app, url, "PUT", preferencesData, access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Notification preferences updated", body["message"])
+		assert.Equal(t, false, models.NotificationEnabled(db, user.ID, models.NotificationOutbid))
+		assert.Equal(t, true, models.NotificationEnabled(db, user.ID, models.NotificationEndingSoon))
+	})
+}
+
 func TestAuctioneer(t *testing.T) {
 	app := fiber.New()
 	db := Setup(t, app)
@@ -229,8 +697,17 @@ func TestAuctioneer(t *testing.T) {
 	updateProfile(t, app, db, BASEURL)
 	getAuctioneerListings(t, app, db, BASEURL)
 	createListing(t, app, db, BASEURL)
+	createListingWithAttributes(t, app, db, BASEURL)
+	importListings(t, app, db, BASEURL)
 	updateListing(t, app, db, BASEURL)
+	updateListingBuyNow(t, app, db, BASEURL)
 	getAuctioneerListingBids(t, app, db, BASEURL)
+	updateListingWithBids(t, app, db, BASEURL)
+	manageListingImages(t, app, db, BASEURL)
+	relistListing(t, app, db, BASEURL)
+	cancelAndDeleteListing(t, app, db, BASEURL)
+	getAuctioneerAnalytics(t, app, db, BASEURL)
+	updateNotificationPreferences(t, app, db, BASEURL)
 
 	// Drop Tables and Close Connectiom
 	DropTables(db)
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+)
+
+func messageBetweenBuyerAndSeller(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	seller := CreateTestVerifiedUser(db)
+	buyer := CreateAnotherTestVerifiedUser(db)
+	staff := CreateTestStaffUser(db)
+	listing := CreateListing(db)
+	db.Create(&models.AuctionResult{ListingId: listing.ID, WinnerId: buyer.ID, UnitPrice: decimal.NewFromInt(1000), Amount: decimal.NewFromInt(1000)})
+
+	t.Run("Message Between Buyer And Seller", func(t *testing.T) {
+		sellerAccess := CreateJwt(db, seller.ID).Access
+		buyerAccess := CreateJwt(db, buyer.ID).Access
+		staffAccess := CreateJwt(db, staff.ID).Access
+
+		// Verify that only winners can start a conversation
+		conversationData := schemas.StartConversationSchema{Listing: *listing.Slug}
+		res := ProcessTestBody(t, app, baseUrl, "POST", conversationData, staffAccess)
+		assert.Equal(t, 403, res.StatusCode)
+
+		// Verify that the winner starts a conversation with the seller
+		res = ProcessTestBody(t, app, baseUrl, "POST", conversationData, buyerAccess)
+		assert.Equal(t, 201, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		conversationId := body["data"].(map[string]interface{})["id"].(string)
+		messagesUrl := fmt.Sprintf("%s/%s/messages", baseUrl, conversationId)
+
+		// Verify that a message with an attachment is sent
+		fileType := "image/jpeg"
+		res = ProcessTestBody(t, app, messagesUrl, "POST", schemas.SendMessageSchema{Body: "Here's the payment receipt", FileType: &fileType}, buyerAccess)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		data := body["data"].(map[string]interface{})
+		assert.NotNil(t, data["attachment_url"])
+		assert.NotNil(t, data["file_upload_data"])
+
+		// Verify that the seller has an unread message
+		req := httptest.NewRequest("GET", baseUrl+"/unread", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sellerAccess))
+		res, _ = app.Test(req)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, float64(1), body["data"].(map[string]interface{})["unread_count"])
+
+		// Verify that staff can read the thread without marking it as read
+		req = httptest.NewRequest("GET", messagesUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", staffAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		res = ProcessTestBody(t, app, messagesUrl, "POST", schemas.SendMessageSchema{Body: "Hello"}, staffAccess)
+		assert.Equal(t, 403, res.StatusCode)
+
+		// Verify that reading the thread marks the message as read
+		req = httptest.NewRequest("GET", messagesUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sellerAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		messages := body["data"].(map[string]interface{})["messages"].([]interface{})
+		assert.Equal(t, 1, len(messages))
+		assert.NotNil(t, messages[0].(map[string]interface{})["read_at"])
+		assert.Equal(t, int64(0), models.UnreadMessagesCount(db, seller.ID))
+	})
+}
+
+func TestConversations(t *testing.T) {
+	app := fiber.New()
+	db := Setup(t, app)
+	BASEURL := "/api/v7/conversations"
+
+	// Run Conversations Endpoint Tests
+	messageBetweenBuyerAndSeller(t, app, db, BASEURL)
+
+	// Drop Tables and Close Connectiom
+	DropTables(db)
+	CloseTestDatabase(db)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+)
+
+func getExchangeRates(t *testing.T, app *fiber.App, baseUrl string) {
+	t.Run("Get Exchange Rates", func(t *testing.T) {
+		// Make request
+		req := httptest.NewRequest("GET", baseUrl, nil)
+		res, _ := app.Test(req)
+
+		// Assert Status code
+		assert.Equal(t, 200, res.StatusCode)
+
+		// Parse and assert body
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Exchange rates fetched", body["message"])
+	})
+}
+
+func updateExchangeRates(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	staff := CreateTestStaffUser(db)
+	t.Run("Update Exchange Rates", func(t *testing.T) {
+		ratesData := schemas.UpdateExchangeRatesSchema{
+			Rates: map[string]decimal.Decimal{"EUR": decimal.NewFromFloat(0.5)},
+		}
+
+		// Verify that non-staff users can't update rates
+		res := ProcessTestBody(t, app, baseUrl, "PUT", ratesData, CreateJwt(db, user.ID).Access)
+		assert.Equal(t, 403, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Staff access only!", body["message"])
+
+		// Verify that staff can update rates
+		res = ProcessTestBody(t, app, baseUrl, "PUT", ratesData, CreateJwt(db, staff.ID).Access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Exchange rates updated", body["message"])
+
+		// Verify that listing prices can be displayed in the new currency
+		listing := CreateListing(db)
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v7/listings/detail/%s", *listing.Slug), nil)
+		req.Header.Set("Accept-Currency", "EUR")
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		display := body["data"].(map[string]interface{})["listing"].(map[string]interface{})["display"].(map[string]interface{})
+		assert.Equal(t, "EUR", display["currency"])
+		assert.Equal(t, "500", display["price"])
+	})
+}
+
+func TestCurrencies(t *testing.T) {
+	app := fiber.New()
+	db := Setup(t, app)
+	BASEURL := "/api/v7/currencies"
+
+	// Run Currencies Endpoint Tests
+	getExchangeRates(t, app, BASEURL)
+	updateExchangeRates(t, app, db, BASEURL)
+
+	// Drop Tables and Close Connectiom
+	DropTables(db)
+	CloseTestDatabase(db)
+}
@@ -46,6 +46,19 @@ func CreateAnotherTestVerifiedUser(db *gorm.DB) models.User {
 	return user
 }
 
+func CreateTestStaffUser(db *gorm.DB) models.User {
+	user := models.User{
+		FirstName: "Test",
+		LastName: "Staff",
+		Email: "teststaffuser@example.com",
+		Password: "testpassword",
+		IsEmailVerified: &truth,
+		IsStaff: &truth,
+	}
+	db.FirstOrCreate(&user, models.User{Email: user.Email})
+	return user
+}
+
 func CreateJwt(db *gorm.DB, userId uuid.UUID) models.Jwt {
 	access := auth.GenerateAccessToken(userId)
 	refresh := auth.GenerateRefreshToken()
@@ -75,4 +88,46 @@ func CreateListing(db *gorm.DB) models.Listing {
 
 	db.Create(&listing)
 	return listing
-}
\ No newline at end of file
+}
+
+func CreateDepositListing(db *gorm.DB) models.Listing {
+	listing := CreateListing(db)
+	listing.RequiresDeposit = true
+	db.Save(&listing)
+	return listing
+}
+
+func CreateLotListing(db *gorm.DB, quantity int, pricingRule string) models.Listing {
+	listing := CreateListing(db)
+	listing.Quantity = quantity
+	listing.PricingRule = pricingRule
+	db.Save(&listing)
+	return listing
+}
+
+func CreateDutchListing(db *gorm.DB) models.Listing {
+	listing := CreateListing(db)
+	floorPrice := decimal.NewFromInt(int64(500))
+	priceDecrement := decimal.NewFromInt(int64(100))
+	decrementMinutes := 60
+	listing.AuctionType = models.AuctionDutch
+	listing.FloorPrice = &floorPrice
+	listing.PriceDecrement = &priceDecrement
+	listing.DecrementMinutes = &decrementMinutes
+	db.Save(&listing)
+	return listing
+}
+
+func CreateReverseListing(db *gorm.DB) models.Listing {
+	listing := CreateListing(db)
+	listing.AuctionType = models.AuctionReverse
+	db.Save(&listing)
+	return listing
+}
+
+func CreateSealedListing(db *gorm.DB) models.Listing {
+	listing := CreateListing(db)
+	listing.AuctionType = models.AuctionSealedSecondPrice
+	db.Save(&listing)
+	return listing
+}
@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 	"github.com/shopspring/decimal"
+	uuid "github.com/satori/go.uuid"
 
+	"github.com/kayprogrammer/bidout-auction-v7/auctions"
 	"github.com/kayprogrammer/bidout-auction-v7/utils"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/realtime"
 	"github.com/kayprogrammer/bidout-auction-v7/schemas"
 )
 
@@ -37,6 +41,129 @@ func getListings(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 
 		data, _ := json.Marshal(body["data"])
 		assert.Equal(t, true, (len(data) > 0))
+
+		// Verify that galleries are left out of list views
+		assert.NotContains(t, body["data"].([]interface{})[0].(map[string]interface{}), "images")
+
+		// Verify that bid totals and watchlist flags are filled in without loading the bids
+		listing := CreateListing(db)
+		bidder := CreateAnotherTestVerifiedUser(db)
+		db.Create(&models.Bid{UserId: bidder.ID, ListingId: listing.ID, Amount: decimal.NewFromInt(1500)})
+		db.Create(&models.Watchlist{UserId: &bidder.ID, ListingId: listing.ID})
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", CreateJwt(db, bidder.ID).Access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		var listingData map[string]interface{}
+		for _, item := range body["data"].([]interface{}) {
+			if item.(map[string]interface{})["slug"] == *listing.Slug {
+				listingData = item.(map[string]interface{})
+			}
+		}
+		assert.NotNil(t, listingData)
+		assert.Equal(t, float64(1), listingData["bids_count"])
+		assert.Equal(t, "1500", listingData["highest_bid"])
+		assert.Equal(t, true, listingData["watchlist"])
+	})
+}
+
+func getPaginatedListings(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Get Paginated Listings", func(t *testing.T) {
+		CreateListing(db)
+		CreateListing(db)
+		CreateListing(db)
+
+		// Verify that the first page has a cursor for the next one
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s?limit=2&sort=price_desc", baseUrl), nil)
+		res, _ := app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, 2, len(body["data"].([]interface{})))
+		meta := body["meta"].(map[string]interface{})
+		assert.GreaterOrEqual(t, meta["total"].(float64), float64(3))
+		assert.NotNil(t, meta["next_cursor"])
+
+		// Verify that the cursor returns the next page
+		req = httptest.NewRequest("GET", fmt.Sprintf("%s?limit=2&sort=price_desc&cursor=%s", baseUrl, meta["next_cursor"]), nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.GreaterOrEqual(t, len(body["data"].([]interface{})), 1)
+
+		// Verify that a cursor can't be reused with another sort
+		req = httptest.NewRequest("GET", fmt.Sprintf("%s?limit=2&sort=newest&cursor=%s", baseUrl, meta["next_cursor"]), nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 422, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"cursor": "Invalid cursor!"}, body["data"].(map[string]interface{}))
+	})
+}
+
+func getMostBidsListings(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	// Give three listings 3, 2 and 1 bids so they sort ahead of the ones without bids
+	bidders := []models.User{CreateAnotherTestVerifiedUser(db), CreateTestUser(db), CreateTestStaffUser(db)}
+	slugs := []string{}
+	for i := 3; i > 0; i-- {
+		listing := CreateListing(db)
+		for j := 0; j < i; j++ {
+			db.Create(&models.Bid{UserId: bidders[j].ID, ListingId: listing.ID, Amount: decimal.NewFromInt(int64(2000 + j))})
+		}
+		slugs = append(slugs, *listing.Slug)
+	}
+
+	t.Run("Get Most Bids Listings", func(t *testing.T) {
+		// Walk the pages and collect the slugs in order
+		seen := []string{}
+		bidsCounts := []float64{}
+		cursor := ""
+		for page := 0; page < 2; page++ {
+			url := fmt.Sprintf("%s?limit=2&sort=most_bids", baseUrl)
+			if cursor != "" {
+				url = fmt.Sprintf("%s&cursor=%s", url, cursor)
+			}
+			res, _ := app.Test(httptest.NewRequest("GET", url, nil))
+			assert.Equal(t, 200, res.StatusCode)
+			body := ParseResponseBody(t, res.Body).(map[string]interface{})
+			for _, item := range body["data"].([]interface{}) {
+				seen = append(seen, item.(map[string]interface{})["slug"].(string))
+				bidsCounts = append(bidsCounts, item.(map[string]interface{})["bids_count"].(float64))
+			}
+			nextCursor, ok := body["meta"].(map[string]interface{})["next_cursor"].(string)
+			assert.Equal(t, true, ok)
+			cursor = nextCursor
+		}
+
+		// Verify that the second page picks up after the first without repeating listings.
+		// The listing with a bid from getListings ties with the last one here
+		assert.Equal(t, 4, len(seen))
+		assert.Equal(t, slugs[:2], seen[:2])
+		assert.Contains(t, seen[2:], slugs[2])
+		assert.NotEqual(t, seen[2], seen[3])
+		assert.Equal(t, []float64{3, 2, 1, 1}, bidsCounts)
+	})
+}
+
+func searchListings(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Search Listings", func(t *testing.T) {
+		CreateListing(db)
+
+		// Verify that partial words match and get highlighted
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/search?q=listi", baseUrl), nil)
+		res, _ := app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Listings search results fetched", body["message"])
+		results := body["data"].([]interface{})
+		assert.GreaterOrEqual(t, len(results), 1)
+		highlights := results[0].(map[string]interface{})["highlights"].(map[string]interface{})
+		assert.Contains(t, highlights["name"], "<mark>")
+
+		// Verify that the search terms are required
+		req = httptest.NewRequest("GET", fmt.Sprintf("%s/search", baseUrl), nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 422, res.StatusCode)
 	})
 }
 
@@ -83,6 +210,38 @@ func getListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 		// Parse and assert body
 		dataKeys := []string{"listing", "related_listings"}
 		assert.Equal(t, true, utils.KeysExistInMap(dataKeys, body["data"].(map[string]interface{})))
+		listingData := body["data"].(map[string]interface{})["listing"].(map[string]interface{})
+		assert.Equal(t, 1, len(listingData["images"].([]interface{})))
+		assert.Equal(t, models.BaseCurrency(), listingData["currency"])
+
+		// Verify that an expired or invalid token doesn't stop the listing from loading
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "Bearer invalid_token")
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+	})
+}
+
+func getRenamedListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateListing(db)
+	oldSlug := *listing.Slug
+	listing.Name = "Renamed Listing"
+	db.Save(&listing)
+
+	t.Run("Get Renamed Listing", func(t *testing.T) {
+		// Verify that the old slug redirects to the new one
+		url := fmt.Sprintf("%s/detail/%s", baseUrl, oldSlug)
+		res, _ := app.Test(httptest.NewRequest("GET", url, nil))
+		assert.Equal(t, 301, res.StatusCode)
+		assert.Equal(t, fmt.Sprintf("%s/detail/%s", baseUrl, *listing.Slug), res.Header.Get("Location"))
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, *listing.Slug, body["data"].(map[string]interface{})["canonical_slug"])
+
+		// Verify that the watchlist accepts the old slug
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/watchlist", baseUrl), "POST", schemas.AddOrRemoveWatchlistSchema{Slug: oldSlug})
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, *listing.Slug, body["data"].(map[string]interface{})["canonical_slug"])
 	})
 }
 
@@ -215,6 +374,76 @@ func getCategoryListings(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl stri
 	})
 }
 
+func manageCategories(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	staff := CreateTestStaffUser(db)
+	listing := CreateListing(db)
+	parent := models.Category{}
+	db.Take(&parent, listing.CategoryId)
+
+	t.Run("Manage Categories", func(t *testing.T) {
+		url := fmt.Sprintf("%s/categories", baseUrl)
+		categoryData := schemas.CreateCategorySchema{Name: "Phones", Parent: parent.Slug}
+
+		// Verify that non-staff users can't create categories
+		res := ProcessTestBody(t, app, url, "POST", categoryData, CreateJwt(db, user.ID).Access)
+		assert.Equal(t, 403, res.StatusCode)
+
+		// Verify that staff can create a subcategory
+		access := CreateJwt(db, staff.ID).Access
+		res = ProcessTestBody(t, app, url, "POST", categoryData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Category created successfully", body["message"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, *parent.Slug, data["parent"])
+		childSlug := data["slug"].(string)
+
+		// Verify that a category can't be moved under its subcategory
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/%s", url, *parent.Slug), "PATCH", schemas.UpdateCategorySchema{Parent: &childSlug}, access)
+		assert.Equal(t, 422, res.StatusCode)
+
+		// Verify that the tree nests the subcategory
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/tree", url), nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Category tree fetched", body["message"])
+		for _, node := range body["data"].([]interface{}) {
+			root := node.(map[string]interface{})
+			if root["slug"] == *parent.Slug {
+				assert.Equal(t, childSlug, root["children"].([]interface{})[0].(map[string]interface{})["slug"])
+			}
+		}
+
+		// Verify that the parent category includes listings of its subcategories
+		child := models.Category{}
+		db.Take(&child, models.Category{Slug: &childSlug})
+		db.Model(&listing).Update("category_id", child.ID)
+		req = httptest.NewRequest("GET", fmt.Sprintf("%s/%s", url, *parent.Slug), nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, 1, len(body["data"].([]interface{})))
+
+		// Verify that a category with subcategories can't be deleted
+		req = httptest.NewRequest("DELETE", fmt.Sprintf("%s/%s", url, *parent.Slug), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ = app.Test(req)
+		assert.Equal(t, 400, res.StatusCode)
+
+		// Verify that a category can't define an attribute its subcategory already has
+		childAttribute := schemas.CategoryAttributeSchema{Name: "storage", Label: "Storage", Type: models.AttributeText}
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/%s/attributes", url, childSlug), "PUT", schemas.UpdateCategoryAttributesSchema{Attributes: []schemas.CategoryAttributeSchema{childAttribute}}, access)
+		assert.Equal(t, 200, res.StatusCode)
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/%s/attributes", url, *parent.Slug), "PUT", schemas.UpdateCategoryAttributesSchema{Attributes: []schemas.CategoryAttributeSchema{childAttribute}}, access)
+		assert.Equal(t, 422, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"attributes": "Duplicate attribute: storage"}, body["data"])
+	})
+}
+
 func getListingBids(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 	listing := CreateListing(db)
 	anotherVerifiedUser := CreateAnotherTestVerifiedUser(db)
@@ -307,7 +536,272 @@ func createBid(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
 		assert.Equal(t, "success", body["status"])
 		assert.Equal(t, "Bid added to listing", body["message"])
 
-		// You can also test for other error responses.....
+		// Verify that english bids can't tie, even when they skip validation
+		tiedBid := models.Bid{UserId: listing.AuctioneerId, ListingId: listing.ID, Amount: decimal.NewFromInt(2000)}
+		assert.NotNil(t, db.Create(&tiedBid).Error)
+	})
+}
+
+func createSealedBid(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateSealedListing(db)
+	anotherVerifiedUser := CreateAnotherTestVerifiedUser(db)
+
+	t.Run("Create Sealed Bid", func(t *testing.T) {
+		url := fmt.Sprintf("%s/detail/%s/bids", baseUrl, *listing.Slug)
+		createBidData := schemas.CreateBidSchema{
+			Amount: 2000.00,
+		}
+		jwt := CreateJwt(db, anotherVerifiedUser.ID)
+
+		// Verify that the sealed bid was created successfully
+		res := ProcessTestBody(t, app, url, "POST", createBidData, jwt.Access)
+		assert.Equal(t, 201, res.StatusCode)
+
+		// Verify that a second sealed bid by the same user fails
+		createBidData.Amount = 3000.00
+		res = ProcessTestBody(t, app, url, "POST", createBidData, jwt.Access)
+		assert.Equal(t, 400, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "You have already placed a sealed bid on this listing!", body["message"])
+
+		// Verify that bid amounts are hidden while the listing is open
+		req := httptest.NewRequest("GET", url, nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		bids := body["data"].(map[string]interface{})["bids"].([]interface{})
+		assert.Equal(t, true, bids[0].(map[string]interface{})["sealed"])
+		assert.Equal(t, "0", bids[0].(map[string]interface{})["amount"])
+
+		// Verify that the winner pays the starting price when unopposed (second-price)
+		results := auctions.Finalize(db, listing.ID)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, anotherVerifiedUser.ID, results[0].WinnerId)
+		assert.Equal(t, true, results[0].Amount.Equal(listing.Price))
+	})
+}
+
+func createDutchBid(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateDutchListing(db)
+	// Two decrements have passed since bidding opened
+	db.Model(&listing).UpdateColumn("created_at", time.Now().Add(-150*time.Minute))
+	anotherVerifiedUser := CreateAnotherTestVerifiedUser(db)
+
+	t.Run("Create Dutch Bid", func(t *testing.T) {
+		url := fmt.Sprintf("%s/detail/%s/bids", baseUrl, *listing.Slug)
+		jwt := CreateJwt(db, anotherVerifiedUser.ID)
+
+		// Verify that the current price is shown and bids below it fail
+		res, _ := app.Test(httptest.NewRequest("GET", fmt.Sprintf("%s/detail/%s", baseUrl, *listing.Slug), nil))
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "800", body["data"].(map[string]interface{})["listing"].(map[string]interface{})["current_price"])
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 700.00}, jwt.Access)
+		assert.Equal(t, 400, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Bid amount cannot be less than the current price!", body["message"])
+
+		// Verify that the first bid at the current price takes the listing
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 800.00}, jwt.Access)
+		assert.Equal(t, 201, res.StatusCode)
+		result := models.AuctionResult{}
+		db.Take(&result, "listing_id = ?", listing.ID)
+		assert.Equal(t, anotherVerifiedUser.ID, result.WinnerId)
+		assert.Equal(t, true, result.Amount.Equal(decimal.NewFromInt(800)))
+		db.Take(&listing, listing.ID)
+		assert.Equal(t, models.ListingStateEnded, listing.State)
+
+		// Verify that the listing can't be bid on afterwards
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 900.00}, CreateJwt(db, CreateTestUser(db).ID).Access)
+		assert.Equal(t, 410, res.StatusCode)
+
+		// Verify that a bid is refused once another one took the listing, even before it's finalized
+		openListing := CreateDutchListing(db)
+		db.Create(&models.Bid{UserId: anotherVerifiedUser.ID, ListingId: openListing.ID, Amount: decimal.NewFromInt(1000)})
+		openUrl := fmt.Sprintf("%s/detail/%s/bids", baseUrl, *openListing.Slug)
+		res = ProcessTestBody(t, app, openUrl, "POST", schemas.CreateBidSchema{Amount: 1000.00}, CreateJwt(db, CreateTestUser(db).ID).Access)
+		assert.Equal(t, 410, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "This auction is closed!", body["message"])
+
+		// Verify that the first bid wins over a higher later one
+		firstBid := models.Bid{BaseModel: models.BaseModel{ID: uuid.NewV4(), CreatedAt: time.Now().Add(-time.Minute)}, UserId: anotherVerifiedUser.ID, Amount: decimal.NewFromInt(800)}
+		laterBid := models.Bid{BaseModel: models.BaseModel{ID: uuid.NewV4(), CreatedAt: time.Now()}, UserId: CreateTestUser(db).ID, Amount: decimal.NewFromInt(900)}
+		results := auctions.Dutch{}.SelectWinners(openListing, []models.Bid{laterBid, firstBid})
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, anotherVerifiedUser.ID, results[0].WinnerId)
+	})
+}
+
+func createReverseBid(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateReverseListing(db)
+	firstBidder := CreateAnotherTestVerifiedUser(db)
+	secondBidder := CreateTestUser(db)
+
+	t.Run("Create Reverse Bid", func(t *testing.T) {
+		url := fmt.Sprintf("%s/detail/%s/bids", baseUrl, *listing.Slug)
+
+		// Verify that bids above the listing price fail
+		res := ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 1100.00}, CreateJwt(db, firstBidder.ID).Access)
+		assert.Equal(t, 400, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Bid amount cannot be more than the listing price!", body["message"])
+
+		// Verify that every bid must undercut the lowest one
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 900.00}, CreateJwt(db, firstBidder.ID).Access)
+		assert.Equal(t, 201, res.StatusCode)
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 900.00}, CreateJwt(db, secondBidder.ID).Access)
+		assert.Equal(t, 400, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Bid amount must be less than the lowest bid!", body["message"])
+		events, unsubscribe := realtime.Subscribe(listing.ID)
+		defer unsubscribe()
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 850.00}, CreateJwt(db, secondBidder.ID).Access)
+		assert.Equal(t, 201, res.StatusCode)
+
+		// Verify that subscribers are told the new lowest bid leads
+		event := <-events
+		assert.Equal(t, realtime.BidCreated, event.Type)
+		event = <-events
+		assert.Equal(t, realtime.HighestBidChanged, event.Type)
+		assert.Equal(t, true, event.Data["highest_bid"].(decimal.Decimal).Equal(decimal.NewFromInt(850)))
+
+		// Verify that the lowest bid wins
+		results := auctions.Finalize(db, listing.ID)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, secondBidder.ID, results[0].WinnerId)
+		assert.Equal(t, true, results[0].Amount.Equal(decimal.NewFromInt(850)))
+	})
+}
+
+func createBidBeforeStart(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateListing(db)
+	startsAt := time.Now().Add(time.Hour)
+	listing.StartsAt = &startsAt
+	db.Save(&listing)
+	anotherVerifiedUser := CreateAnotherTestVerifiedUser(db)
+
+	t.Run("Create Bid Before Start", func(t *testing.T) {
+		url := fmt.Sprintf("%s/detail/%s/bids", baseUrl, *listing.Slug)
+		createBidData := schemas.CreateBidSchema{
+			Amount: 2000.00,
+		}
+		jwt := CreateJwt(db, anotherVerifiedUser.ID)
+
+		// Verify that bidding fails before the auction starts
+		res := ProcessTestBody(t, app, url, "POST", createBidData, jwt.Access)
+		assert.Equal(t, 400, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "This auction hasn't started yet!", body["message"])
+
+		// Verify that the listing shows up as upcoming
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s?status=upcoming", baseUrl), nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		listings := body["data"].([]interface{})
+		assert.Equal(t, 1, len(listings))
+		assert.Equal(t, "upcoming", listings[0].(map[string]interface{})["status"])
+	})
+}
+
+func createLotBids(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateLotListing(db, 3, models.PricingUniform)
+	firstBidder := CreateAnotherTestVerifiedUser(db)
+	secondBidder := CreateTestUser(db)
+
+	t.Run("Create Lot Bids", func(t *testing.T) {
+		url := fmt.Sprintf("%s/detail/%s/bids", baseUrl, *listing.Slug)
+		quantity := 2
+		createBidData := schemas.CreateBidSchema{Amount: 2000.00, Quantity: &quantity}
+
+		// Verify that bids for units of the lot succeed
+		res := ProcessTestBody(t, app, url, "POST", createBidData, CreateJwt(db, firstBidder.ID).Access)
+		assert.Equal(t, 201, res.StatusCode)
+		createBidData.Amount = 1500.00
+		res = ProcessTestBody(t, app, url, "POST", createBidData, CreateJwt(db, secondBidder.ID).Access)
+		assert.Equal(t, 201, res.StatusCode)
+
+		// Verify that asking for more units than the lot has fails
+		quantity = 4
+		res = ProcessTestBody(t, app, url, "POST", createBidData, CreateJwt(db, secondBidder.ID).Access)
+		assert.Equal(t, 400, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Requested quantity exceeds the lot size!", body["message"])
+
+		// Verify that units go to the highest bids at the lowest winning bid (uniform)
+		results := auctions.Finalize(db, listing.ID)
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, firstBidder.ID, results[0].WinnerId)
+		assert.Equal(t, 2, results[0].Quantity)
+		assert.Equal(t, secondBidder.ID, results[1].WinnerId)
+		assert.Equal(t, 1, results[1].Quantity)
+		assert.Equal(t, true, results[0].UnitPrice.Equal(decimal.NewFromInt(1500)))
+		assert.Equal(t, true, results[1].Amount.Equal(decimal.NewFromInt(1500)))
+	})
+}
+
+func buyListingNow(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateListing(db)
+	buyNowPrice := decimal.NewFromInt(int64(5000))
+	listing.BuyNowPrice = &buyNowPrice
+	db.Save(&listing)
+	anotherVerifiedUser := CreateAnotherTestVerifiedUser(db)
+
+	t.Run("Buy Listing Now", func(t *testing.T) {
+		url := fmt.Sprintf("%s/detail/%s/buy-now", baseUrl, *listing.Slug)
+
+		// Verify that the auctioneer cannot buy their own listing
+		jwt := CreateJwt(db, listing.AuctioneerId)
+		res := ProcessTestBody(t, app, url, "POST", nil, jwt.Access)
+		assert.Equal(t, 403, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "You cannot buy your own product!", body["message"])
+
+		// Verify that the listing was bought successfully
+		jwt = CreateJwt(db, anotherVerifiedUser.ID)
+		res = ProcessTestBody(t, app, url, "POST", nil, jwt.Access)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Listing bought successfully", body["message"])
+		assert.Equal(t, true, body["data"].(map[string]interface{})["buy_now"])
+
+		// Verify that the listing can't be bought twice
+		res = ProcessTestBody(t, app, url, "POST", nil, jwt.Access)
+		assert.Equal(t, 410, res.StatusCode)
+	})
+}
+
+func getListingEvents(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	listing := CreateListing(db)
+	anotherVerifiedUser := CreateAnotherTestVerifiedUser(db)
+
+	t.Run("Get Listing Events", func(t *testing.T) {
+		// Verify that streaming an invalid listing fails
+		url := fmt.Sprintf("%s/detail/invalid_listing_slug/events", baseUrl)
+		req := httptest.NewRequest("GET", url, nil)
+		res, _ := app.Test(req)
+		assert.Equal(t, 404, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Listing does not exist!", body["message"])
+
+		// Verify that subscribers receive new bids
+		events, unsubscribe := realtime.Subscribe(listing.ID)
+		defer unsubscribe()
+		url = fmt.Sprintf("%s/detail/%s/bids", baseUrl, *listing.Slug)
+		jwt := CreateJwt(db, anotherVerifiedUser.ID)
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 2000.00}, jwt.Access)
+		assert.Equal(t, 201, res.StatusCode)
+
+		event := <-events
+		assert.Equal(t, realtime.BidCreated, event.Type)
+		event = <-events
+		assert.Equal(t, realtime.HighestBidChanged, event.Type)
+		assert.Equal(t, true, event.Data["highest_bid"].(decimal.Decimal).Equal(decimal.NewFromInt(2000)))
 	})
 }
 
@@ -318,13 +812,25 @@ func TestListing(t *testing.T) {
 
 	// Run Listings Endpoint Tests
 	getListings(t, app, db, BASEURL)
+	getPaginatedListings(t, app, db, BASEURL)
+	getMostBidsListings(t, app, db, BASEURL)
+	searchListings(t, app, db, BASEURL)
 	getListing(t, app, db, BASEURL)
+	getRenamedListing(t, app, db, BASEURL)
 	getWatchlistListings(t, app, db, BASEURL)
 	createOrRemoveUserWatchlistsListing(t, app, db, BASEURL)
 	getCategories(t, app, db, BASEURL)
 	getCategoryListings(t, app, db, BASEURL)
+	manageCategories(t, app, db, BASEURL)
 	getListingBids(t, app, db, BASEURL)
 	createBid(t, app, db, BASEURL)
+	createSealedBid(t, app, db, BASEURL)
+	createDutchBid(t, app, db, BASEURL)
+	createReverseBid(t, app, db, BASEURL)
+	createBidBeforeStart(t, app, db, BASEURL)
+	createLotBids(t, app, db, BASEURL)
+	buyListingNow(t, app, db, BASEURL)
+	getListingEvents(t, app, db, BASEURL)
 
 	// Drop Tables and Close Connectiom
 	DropTables(db)
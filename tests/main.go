@@ -15,6 +15,7 @@ import (
 	"github.com/kayprogrammer/bidout-auction-v7/config"
 	"github.com/kayprogrammer/bidout-auction-v7/models"
 	"github.com/kayprogrammer/bidout-auction-v7/routes"
+	"github.com/kayprogrammer/bidout-auction-v7/utils"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -38,10 +39,40 @@ func CreateTables(db *gorm.DB) {
 
 		// listings
 		&models.Category{}, 
+		&models.CategoryAttribute{},
 		&models.Listing{}, 
+		&models.ListingImage{},
+		&models.ListingRevision{},
+		&models.SlugHistory{},
+		&models.ListingStat{},
+		&models.ListingViewer{},
+		&models.ListingQuestion{},
 		&models.Bid{},
 		&models.Watchlist{},
+		&models.AuctionResult{},
+		&models.Conversation{},
+		&models.Message{},
+
+		// notifications
+		&models.Notification{},
+		&models.NotificationPreference{},
+
+		// wallets
+		&models.LedgerTransaction{},
+		&models.LedgerEntry{},
+
+		// currencies
+		&models.ExchangeRate{},
+
+		// saved searches
+		&models.SavedSearch{},
 	)
+	if err := models.SetupBidIndexes(db); err != nil {
+		log.Fatal("Failed to set up bid indexes: " + err.Error())
+	}
+	if err := models.SetupListingSearch(db); err != nil {
+		log.Fatal("Failed to set up listing search: " + err.Error())
+	}
 }
 
 func DropTables(db *gorm.DB) {
@@ -62,9 +93,33 @@ func DropTables(db *gorm.DB) {
 
 		// listings
 		&models.Category{}, 
+		&models.CategoryAttribute{},
 		&models.Listing{}, 
+		&models.ListingImage{},
+		&models.ListingRevision{},
+		&models.SlugHistory{},
+		&models.ListingStat{},
+		&models.ListingViewer{},
+		&models.ListingQuestion{},
 		&models.Bid{},
 		&models.Watchlist{},
+		&models.AuctionResult{},
+		&models.Conversation{},
+		&models.Message{},
+
+		// notifications
+		&models.Notification{},
+		&models.NotificationPreference{},
+
+		// wallets
+		&models.LedgerTransaction{},
+		&models.LedgerEntry{},
+
+		// currencies
+		&models.ExchangeRate{},
+
+		// saved searches
+		&models.SavedSearch{},
 	)
 }
 
@@ -131,6 +186,9 @@ func Setup(t *testing.T, app *fiber.App) *gorm.DB {
 	// Set up the test database
 	db := SetupTestDatabase(t)
 
+	// Keep uploads on disk so file URLs work without cloud credentials
+	utils.SetFileStorage(utils.NewLocalStorage(t.TempDir(), "http://localhost:8000", config.GetConfig().SecretKey))
+
 	// Inject your test database and environment text into the Fiber app's context
 	app.Use(func(c *fiber.Ctx) error {
 		c.Locals("db", db)
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+)
+
+func moderateListing(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	staff := CreateTestStaffUser(db)
+	listing := CreateListing(db)
+	db.Model(&listing).Update("state", models.ListingStatePendingReview)
+
+	t.Run("Moderate Listing", func(t *testing.T) {
+		userAccess := CreateJwt(db, user.ID).Access
+		staffAccess := CreateJwt(db, staff.ID).Access
+		detailUrl := fmt.Sprintf("/api/v7/listings/detail/%s", *listing.Slug)
+
+		// Verify that listings pending review are hidden
+		res, _ := app.Test(httptest.NewRequest("GET", detailUrl, nil))
+		assert.Equal(t, 404, res.StatusCode)
+
+		// Verify that non-staff users can't see the queue
+		req := httptest.NewRequest("GET", baseUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 403, res.StatusCode)
+
+		// Verify that staff see the listing in the queue
+		req = httptest.NewRequest("GET", baseUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", staffAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Moderation queue fetched", body["message"])
+		assert.Equal(t, 1, len(body["data"].([]interface{})))
+
+		// Verify that a rejection needs a reason
+		rejectUrl := fmt.Sprintf("%s/%s/reject", baseUrl, *listing.Slug)
+		res = ProcessTestBody(t, app, rejectUrl, "POST", schemas.RejectListingSchema{}, staffAccess)
+		assert.Equal(t, 422, res.StatusCode)
+
+		// Verify that the listing is rejected with the reason
+		res = ProcessTestBody(t, app, rejectUrl, "POST", schemas.RejectListingSchema{Reason: "Blurry photos"}, staffAccess)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Listing rejected", body["message"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, models.ListingStateRejected, data["state"])
+		assert.Equal(t, "Blurry photos", data["rejection_reason"])
+
+		// Verify that the seller can submit it again
+		submitUrl := fmt.Sprintf("/api/v7/auctioneer/listings/%s/submit", *listing.Slug)
+		req = httptest.NewRequest("POST", submitUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", userAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Listing submitted for review", body["message"])
+		assert.Equal(t, models.ListingStatePendingReview, body["data"].(map[string]interface{})["state"])
+
+		// Verify that the approved listing is published
+		req = httptest.NewRequest("POST", fmt.Sprintf("%s/%s/approve", baseUrl, *listing.Slug), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", staffAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Listing approved", body["message"])
+		assert.Equal(t, models.ListingStatePublished, body["data"].(map[string]interface{})["state"])
+
+		res, _ = app.Test(httptest.NewRequest("GET", detailUrl, nil))
+		assert.Equal(t, 200, res.StatusCode)
+	})
+}
+
+func TestModeration(t *testing.T) {
+	app := fiber.New()
+	db := Setup(t, app)
+	BASEURL := "/api/v7/moderation/listings"
+
+	// Run Moderation Endpoint Tests
+	moderateListing(t, app, db, BASEURL)
+
+	// Drop Tables and Close Connectiom
+	DropTables(db)
+	CloseTestDatabase(db)
+}
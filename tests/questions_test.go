@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+)
+
+func askAndAnswerQuestion(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	seller := CreateTestVerifiedUser(db)
+	buyer := CreateAnotherTestVerifiedUser(db)
+	staff := CreateTestStaffUser(db)
+	listing := CreateListing(db)
+
+	t.Run("Ask And Answer Question", func(t *testing.T) {
+		sellerAccess := CreateJwt(db, seller.ID).Access
+		buyerAccess := CreateJwt(db, buyer.ID).Access
+		staffAccess := CreateJwt(db, staff.ID).Access
+		detailUrl := fmt.Sprintf("%s/detail/%s", baseUrl, *listing.Slug)
+
+		// Verify that sellers can't ask about their own listings
+		questionData := schemas.AskListingQuestionSchema{Question: "Does it come with a charger?"}
+		res := ProcessTestBody(t, app, detailUrl+"/questions", "POST", questionData, sellerAccess)
+		assert.Equal(t, 403, res.StatusCode)
+
+		// Verify that a question is asked successfully
+		res = ProcessTestBody(t, app, detailUrl+"/questions", "POST", questionData, buyerAccess)
+		assert.Equal(t, 201, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Question asked successfully", body["message"])
+		questionId := body["data"].(map[string]interface{})["id"].(string)
+
+		// Verify that only the seller can answer it
+		answerUrl := fmt.Sprintf("/api/v7/auctioneer/listings/%s/questions/%s/answer", *listing.Slug, questionId)
+		answerData := schemas.AnswerListingQuestionSchema{Answer: "Yes, the original one."}
+		res = ProcessTestBody(t, app, answerUrl, "POST", answerData, buyerAccess)
+		assert.Equal(t, 400, res.StatusCode)
+		res = ProcessTestBody(t, app, answerUrl, "POST", answerData, sellerAccess)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Yes, the original one.", body["data"].(map[string]interface{})["answer"])
+
+		// Verify that the thread is shown on the listing
+		res, _ = app.Test(httptest.NewRequest("GET", detailUrl+"?questions_limit=5", nil))
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		questions := body["data"].(map[string]interface{})["questions"].(map[string]interface{})
+		assert.Equal(t, 1, len(questions["items"].([]interface{})))
+		assert.Equal(t, float64(5), questions["meta"].(map[string]interface{})["limit"])
+
+		// Verify that staff can hide the question
+		hidden := true
+		reason := "Off topic"
+		moderateUrl := fmt.Sprintf("/api/v7/moderation/questions/%s", questionId)
+		res = ProcessTestBody(t, app, moderateUrl, "PATCH", schemas.ModerateListingQuestionSchema{Hidden: &hidden, Reason: &reason}, buyerAccess)
+		assert.Equal(t, 403, res.StatusCode)
+		res = ProcessTestBody(t, app, moderateUrl, "PATCH", schemas.ModerateListingQuestionSchema{Hidden: &hidden, Reason: &reason}, staffAccess)
+		assert.Equal(t, 200, res.StatusCode)
+
+		res, _ = app.Test(httptest.NewRequest("GET", detailUrl, nil))
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		questions = body["data"].(map[string]interface{})["questions"].(map[string]interface{})
+		assert.Equal(t, 0, len(questions["items"].([]interface{})))
+
+		req := httptest.NewRequest("GET", "/api/v7/moderation/questions?hidden=true", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", staffAccess))
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, 1, len(body["data"].([]interface{})))
+	})
+}
+
+func TestQuestions(t *testing.T) {
+	app := fiber.New()
+	db := Setup(t, app)
+	BASEURL := "/api/v7/listings"
+
+	// Run Questions Endpoint Tests
+	askAndAnswerQuestion(t, app, db, BASEURL)
+
+	// Drop Tables and Close Connectiom
+	DropTables(db)
+	CloseTestDatabase(db)
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+)
+
+func createSavedSearch(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Create Saved Search", func(t *testing.T) {
+		user := CreateTestVerifiedUser(db)
+		token := CreateJwt(db, user.ID).Access
+		category := "invalid_category"
+		searchData := schemas.CreateSavedSearchSchema{
+			Name:     "Phones",
+			Query:    "phone",
+			Category: &category,
+		}
+
+		// Verify that a saved search can't be created with an invalid category
+		res := ProcessTestBody(t, app, baseUrl, "POST", searchData, token)
+		assert.Equal(t, 422, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Invalid Entry", body["message"])
+		assert.Equal(t, map[string]interface{}{"category": "Invalid category!"}, body["data"])
+
+		// Verify that a saved search is created successfully
+		searchData.Category = nil
+		res = ProcessTestBody(t, app, baseUrl, "POST", searchData, token)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Search saved successfully", body["message"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "Phones", data["name"])
+		assert.Equal(t, models.AlertInstant, data["frequency"])
+	})
+}
+
+func getSavedSearches(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Get Saved Searches", func(t *testing.T) {
+		user := CreateTestVerifiedUser(db)
+		db.Create(&models.SavedSearch{UserId: user.ID, Name: "Phones", Query: "phone", Frequency: models.AlertDigest})
+
+		// Make request
+		req := httptest.NewRequest("GET", baseUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", CreateJwt(db, user.ID).Access))
+		res, _ := app.Test(req)
+
+		// Assert Status code
+		assert.Equal(t, 200, res.StatusCode)
+
+		// Parse and assert body
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Saved searches fetched", body["message"])
+		assert.Equal(t, 1, len(body["data"].([]interface{})))
+	})
+}
+
+func updateSavedSearch(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Update Saved Search", func(t *testing.T) {
+		user := CreateTestVerifiedUser(db)
+		search := models.SavedSearch{UserId: user.ID, Name: "Phones", Query: "phone", Frequency: models.AlertInstant}
+		db.Create(&search)
+		frequency := models.AlertDigest
+		searchData := schemas.UpdateSavedSearchSchema{Frequency: &frequency}
+
+		// Verify that another user's saved search can't be updated
+		otherUser := CreateAnotherTestVerifiedUser(db)
+		url := fmt.Sprintf("%s/%s", baseUrl, search.ID)
+		res := ProcessTestBody(t, app, url, "PATCH", searchData, CreateJwt(db, otherUser.ID).Access)
+		assert.Equal(t, 404, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Saved search does not exist!", body["message"])
+
+		// Verify that the saved search is updated successfully
+		res = ProcessTestBody(t, app, url, "PATCH", searchData, CreateJwt(db, user.ID).Access)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Saved search updated", body["message"])
+		assert.Equal(t, models.AlertDigest, body["data"].(map[string]interface{})["frequency"])
+	})
+}
+
+func deleteSavedSearch(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Delete Saved Search", func(t *testing.T) {
+		user := CreateTestVerifiedUser(db)
+		search := models.SavedSearch{UserId: user.ID, Name: "Phones", Query: "phone", Frequency: models.AlertInstant}
+		db.Create(&search)
+
+		// Make request
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("%s/%s", baseUrl, search.ID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", CreateJwt(db, user.ID).Access))
+		res, _ := app.Test(req)
+
+		// Assert response
+		assert.Equal(t, 200, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Saved search deleted", body["message"])
+	})
+}
+
+func unsubscribeSavedSearch(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Unsubscribe Saved Search", func(t *testing.T) {
+		user := CreateTestVerifiedUser(db)
+		search := models.SavedSearch{UserId: user.ID, Name: "Phones", Query: "phone", Frequency: models.AlertInstant}
+		db.Create(&search)
+
+		// Verify that an invalid token is rejected
+		req := httptest.NewRequest("GET", fmt.Sprintf("%s/unsubscribe/invalid_token", baseUrl), nil)
+		res, _ := app.Test(req)
+		assert.Equal(t, 404, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Invalid unsubscribe link!", body["message"])
+
+		// Verify that opening the link only asks for confirmation
+		url := fmt.Sprintf("%s/unsubscribe/%s", baseUrl, search.UnsubscribeToken)
+		req = httptest.NewRequest("GET", url, nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "Confirm to unsubscribe from this saved search", body["message"])
+		assert.Equal(t, "Phones", body["data"].(map[string]interface{})["name"])
+		var count int64
+		db.Model(&models.SavedSearch{}).Where("id = ?", search.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		// Verify that a confirmed token unsubscribes without login
+		req = httptest.NewRequest("POST", url, nil)
+		res, _ = app.Test(req)
+		assert.Equal(t, 200, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Unsubscribed successfully", body["message"])
+		db.Model(&models.SavedSearch{}).Where("id = ?", search.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func matchSavedSearchCategories(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	t.Run("Match Saved Search Categories", func(t *testing.T) {
+		user := CreateAnotherTestVerifiedUser(db)
+		parent := models.Category{Name: "Gadgets"}
+		db.Create(&parent)
+		child := models.Category{Name: "Smartwatches", ParentId: &parent.ID}
+		db.Create(&child)
+		other := models.Category{Name: "Furniture"}
+		db.Create(&other)
+		listing := CreateListing(db)
+		listing.CategoryId = &child.ID
+		db.Save(&listing)
+
+		parentSearch := models.SavedSearch{UserId: user.ID, Name: "Gadgets", Query: "listing", CategoryId: &parent.ID, Frequency: models.AlertInstant}
+		db.Create(&parentSearch)
+		otherSearch := models.SavedSearch{UserId: user.ID, Name: "Furniture", Query: "listing", CategoryId: &other.ID, Frequency: models.AlertInstant}
+		db.Create(&otherSearch)
+
+		// Verify that a search on a category matches listings in its subcategories only
+		searchIds := []interface{}{}
+		for _, search := range models.MatchingSavedSearches(db, listing.ID, models.AlertInstant) {
+			searchIds = append(searchIds, search.ID)
+		}
+		assert.Contains(t, searchIds, parentSearch.ID)
+		assert.NotContains(t, searchIds, otherSearch.ID)
+	})
+}
+
+func TestSavedSearches(t *testing.T) {
+	app := fiber.New()
+	db := Setup(t, app)
+	BASEURL := "/api/v7/saved-searches"
+
+	// Run Saved Searches Endpoint Tests
+	getSavedSearches(t, app, db, BASEURL)
+	createSavedSearch(t, app, db, BASEURL)
+	updateSavedSearch(t, app, db, BASEURL)
+	deleteSavedSearch(t, app, db, BASEURL)
+	unsubscribeSavedSearch(t, app, db, BASEURL)
+	matchSavedSearchCategories(t, app, db, BASEURL)
+
+	// Drop Tables and Close Connectiom
+	DropTables(db)
+	CloseTestDatabase(db)
+}
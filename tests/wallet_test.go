@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	uuid "github.com/satori/go.uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/kayprogrammer/bidout-auction-v7/auctions"
+	"github.com/kayprogrammer/bidout-auction-v7/models"
+	"github.com/kayprogrammer/bidout-auction-v7/payments"
+	"github.com/kayprogrammer/bidout-auction-v7/schemas"
+)
+
+// A payment provider whose payouts always fail
+type failingPayoutProvider struct {
+	payments.LocalProvider
+}
+
+func (failingPayoutProvider) Payout(userId uuid.UUID, amount decimal.Decimal) (string, error) {
+	return "", errors.New("payout declined")
+}
+
+func getWallet(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	t.Run("Get Wallet", func(t *testing.T) {
+		// Make request
+		req := httptest.NewRequest("GET", baseUrl, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", access))
+		res, _ := app.Test(req)
+
+		// Assert Status code
+		assert.Equal(t, 200, res.StatusCode)
+
+		// Parse and assert body
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Wallet fetched", body["message"])
+	})
+}
+
+func depositAndWithdraw(t *testing.T, app *fiber.App, db *gorm.DB, baseUrl string) {
+	user := CreateTestVerifiedUser(db)
+	access := CreateJwt(db, user.ID).Access
+	t.Run("Deposit And Withdraw", func(t *testing.T) {
+		walletData := schemas.WalletAmountSchema{Amount: 1000.00}
+
+		// Verify that the deposit succeeds
+		res := ProcessTestBody(t, app, fmt.Sprintf("%s/deposit", baseUrl), "POST", walletData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Deposit successful", body["message"])
+		available := payments.AvailableBalance(db, user.ID)
+
+		// Verify that withdrawing more than the available balance fails
+		walletData.Amount, _ = available.Add(decimal.NewFromInt(1)).Float64()
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/withdraw", baseUrl), "POST", walletData, access)
+		assert.Equal(t, 402, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Insufficient wallet balance!", body["message"])
+
+		// Verify that the withdrawal succeeds
+		walletData.Amount = 400.00
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/withdraw", baseUrl), "POST", walletData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		body = ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "success", body["status"])
+		assert.Equal(t, "Withdrawal successful", body["message"])
+		assert.Equal(t, true, payments.AvailableBalance(db, user.ID).Equal(available.Sub(decimal.NewFromInt(400))))
+		assert.Equal(t, models.TransactionCompleted, body["data"].(map[string]interface{})["status"])
+
+		// Verify that a failed payout is reversed back to the available balance
+		available = payments.AvailableBalance(db, user.ID)
+		payments.SetProvider(failingPayoutProvider{})
+		defer payments.SetProvider(payments.LocalProvider{})
+		res = ProcessTestBody(t, app, fmt.Sprintf("%s/withdraw", baseUrl), "POST", walletData, access)
+		assert.Equal(t, 502, res.StatusCode)
+		assert.Equal(t, true, payments.AvailableBalance(db, user.ID).Equal(available))
+		transaction := models.LedgerTransaction{}
+		db.Where("user_id = ? AND kind = ?", user.ID, models.TransactionWithdrawal).Order("created_at DESC").Take(&transaction)
+		assert.Equal(t, models.TransactionReversed, transaction.Status)
+	})
+}
+
+func bidWithDeposit(t *testing.T, app *fiber.App, db *gorm.DB) {
+	listing := CreateDepositListing(db)
+	bidder := CreateAnotherTestVerifiedUser(db)
+	access := CreateJwt(db, bidder.ID).Access
+	t.Run("Bid With Deposit", func(t *testing.T) {
+		url := fmt.Sprintf("/api/v7/listings/detail/%s/bids", *listing.Slug)
+		createBidData := schemas.CreateBidSchema{Amount: 2000.00}
+
+		// Verify that bidding without enough balance fails
+		res := ProcessTestBody(t, app, url, "POST", createBidData, access)
+		assert.Equal(t, 402, res.StatusCode)
+		body := ParseResponseBody(t, res.Body).(map[string]interface{})
+		assert.Equal(t, "failure", body["status"])
+		assert.Equal(t, "Insufficient wallet balance for this bid!", body["message"])
+
+		// Verify that the bid amount is held once the bidder has funds
+		payments.Deposit(db, bidder.ID, decimal.NewFromInt(2500))
+		res = ProcessTestBody(t, app, url, "POST", createBidData, access)
+		assert.Equal(t, 201, res.StatusCode)
+		assert.Equal(t, true, payments.HeldBalance(db, bidder.ID, &listing.ID).Equal(decimal.NewFromInt(2000)))
+
+		// Verify that the winner's hold is captured when the listing is finalized
+		auctions.Finalize(db, listing.ID)
+		assert.Equal(t, true, payments.HeldBalance(db, bidder.ID, &listing.ID).IsZero())
+		assert.Equal(t, true, payments.AvailableBalance(db, bidder.ID).Equal(decimal.NewFromInt(500)))
+	})
+}
+
+func outbidReleasesDeposit(t *testing.T, app *fiber.App, db *gorm.DB) {
+	listing := CreateDepositListing(db)
+	bidder := CreateAnotherTestVerifiedUser(db)
+	anotherBidder := CreateTestStaffUser(db)
+	payments.Deposit(db, bidder.ID, decimal.NewFromInt(3000))
+	payments.Deposit(db, anotherBidder.ID, decimal.NewFromInt(3000))
+	t.Run("Outbid Releases Deposit", func(t *testing.T) {
+		url := fmt.Sprintf("/api/v7/listings/detail/%s/bids", *listing.Slug)
+		res := ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 2000.00}, CreateJwt(db, bidder.ID).Access)
+		assert.Equal(t, 201, res.StatusCode)
+
+		// Verify that the outbid bidder's hold is released with the new bid
+		res = ProcessTestBody(t, app, url, "POST", schemas.CreateBidSchema{Amount: 2500.00}, CreateJwt(db, anotherBidder.ID).Access)
+		assert.Equal(t, 201, res.StatusCode)
+		assert.Equal(t, true, payments.HeldBalance(db, bidder.ID, &listing.ID).IsZero())
+		assert.Equal(t, true, payments.HeldBalance(db, anotherBidder.ID, &listing.ID).Equal(decimal.NewFromInt(2500)))
+	})
+}
+
+func TestWallet(t *testing.T) {
+	app := fiber.New()
+	db := Setup(t, app)
+	BASEURL := "/api/v7/wallet"
+
+	// Run Wallet Endpoint Tests
+	getWallet(t, app, db, BASEURL)
+	depositAndWithdraw(t, app, db, BASEURL)
+	bidWithDeposit(t, app, db)
+	outbidReleasesDeposit(t, app, db)
+
+	// Drop Tables and Close Connectiom
+	DropTables(db)
+	CloseTestDatabase(db)
+}
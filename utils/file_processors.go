@@ -1,42 +1,20 @@
 package utils
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
-	"time"
-
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-
-	"github.com/kayprogrammer/bidout-auction-v7/config"
-
 )
 
-var cloudName string
-var apiKey string
-var apiSecret string 
-var cld *cloudinary.Cloudinary
 var baseFolder = "bidout-auction-v7/"
 
-func init() {
-	cfg := config.GetConfig()
-	// Load environment variables here
-	cloudName = cfg.CloudinaryCloudName
-	apiKey = cfg.CloudinaryAPIKey
-	apiSecret = cfg.CloudinaryAPISecret
-
-	// Initialize the Cloudinary client
-	cld, _ = cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
-}
-
 type SignatureFormat struct {
 	PublicId  string `json:"public_id" example:"images/f47ac10b-58cc-4372-a567-0e02b2c3d479"`
 	Signature string `json:"signature" example:"e1ba4683fbbf90b75ca22e9f8e545b18c6b24eae"`
 	Timestamp int64  `json:"timestamp" example:"1678828200"`
+	UploadUrl string `json:"upload_url" example:"https://api.cloudinary.com/v1_1/demo/image/upload"`
+	Method    string `json:"method" example:"POST"`
+	FileId    string `json:"file_id" example:"f47ac10b-58cc-4372-a567-0e02b2c3d479"`
 }
 
 var ImageExtensions = map[string]string{
@@ -48,40 +26,30 @@ var ImageExtensions = map[string]string{
 	"image/tiff": "tiff",
 }
 
-func GenerateFileSignature(key string, folder string) SignatureFormat {
-	key = fmt.Sprintf("%s%s/%s", baseFolder, folder, key)
-	timestamp := time.Now().Unix()
-	params := map[string]interface{}{
-		"public_id": key,
-		"timestamp": timestamp,
+// Returns the content type of files with the extension, or an empty string if it isn't allowed
+func ContentTypeForExtension(extension string) string {
+	for contentType, ext := range ImageExtensions {
+		if ext == extension {
+			return contentType
+		}
 	}
+	return ""
+}
 
-	// Convert the params to url.Values
-	values := url.Values{}
-	for k, v := range params {
-		values.Add(k, fmt.Sprintf("%v", v))
-	}
-	resp, err := api.SignParameters(values, apiSecret)
-	if err != nil {
-		log.Fatal("Error signing params: ", err)
-	}
-	signatureResp := SignatureFormat{PublicId: key, Signature: resp, Timestamp: timestamp}
-	return signatureResp
+// Returns the key a file is stored under in a folder
+func FileKey(key string, folder string, contentType string) string {
+	return fmt.Sprintf("%s%s/%s.%s", baseFolder, folder, key, ImageExtensions[contentType])
 }
 
-func GenerateFileUrl(key string, folder string, contentType string) string {
-	key = fmt.Sprintf("%s%s/%s.%s", baseFolder, folder, key, ImageExtensions[contentType])
+func GenerateFileSignature(key string, folder string, contentType string) SignatureFormat {
+	uploadData := FileStorage().UploadData(FileKey(key, folder, contentType), contentType)
+	// Clients confirm the upload with the file's id once it's done
+	uploadData.FileId = key
+	return uploadData
+}
 
-	// Generate the Cloudinary URL for the existing resource
-	urls, err := cld.Media(key)
-	if err != nil {
-		log.Println("Error generating Cloudinary URL:", err)
-	}
-	url, err := urls.String()
-	if err != nil {
-		log.Println("Error converting to string:", err)
-	}
-	return url
+func GenerateFileUrl(key string, folder string, contentType string) string {
+	return FileStorage().Url(FileKey(key, folder, contentType))
 }
 
 func BoolAddr(b bool) *bool {
@@ -89,9 +57,8 @@ func BoolAddr(b bool) *bool {
 	return &boolVar
 }
 
-func UploadImage(file *os.File, key string, folder string) {
-	key = fmt.Sprintf("%s%s/%s", baseFolder, folder, key)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	cld.Upload.Upload(ctx, file, uploader.UploadParams{PublicID: key, Overwrite: BoolAddr(true), Faces: BoolAddr(true)})
+func UploadImage(file *os.File, key string, folder string, contentType string) {
+	if err := FileStorage().Upload(file, FileKey(key, folder, contentType), contentType); err != nil {
+		log.Println("Error uploading image:", err)
+	}
 }
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/kayprogrammer/bidout-auction-v7/config"
+)
+
+// Storage keeps uploaded files. Clients upload straight to it with the data UploadData returns,
+// so files never pass through the API. Keys look like 'bidout-auction-v7/listings/<id>.jpg'
+type Storage interface {
+	// Returns what a client needs to upload a file under the key
+	UploadData(key string, contentType string) SignatureFormat
+	// Returns the URL the file under the key is served from
+	Url(key string) string
+	// Uploads a file from the server, e.g the initial listing images
+	Upload(file io.Reader, key string, contentType string) error
+	// Returns what storage knows about the file under the key, or ErrFileNotFound
+	Stat(key string) (StoredFile, error)
+	// Deletes the file under the key. Deleting a missing file isn't an error
+	Delete(key string) error
+}
+
+// A file as kept in storage
+type StoredFile struct {
+	ContentType			string
+	Size				int64
+}
+
+var ErrFileNotFound = errors.New("file not found")
+
+// Storage backends
+const (
+	StorageCloudinary		= "cloudinary"
+	StorageLocal			= "local"
+	StorageS3				= "s3"
+)
+
+// How long upload data stays valid once it's handed to a client
+const UploadExpiry = time.Hour
+
+var (
+	fileStorage			Storage
+	fileStorageMu		sync.RWMutex
+)
+
+// Builds the storage backend chosen in the config
+func NewStorage(cfg *config.Configuration) Storage {
+	switch cfg.StorageBackend {
+	case StorageCloudinary:
+		return NewCloudinaryStorage(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	case StorageLocal:
+		return NewLocalStorage(cfg.LocalStorageDir, cfg.LocalStorageURL, cfg.SecretKey)
+	case StorageS3:
+		return NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretAccessKey, cfg.S3PublicURL)
+	}
+	log.Fatalf("Unknown storage backend: %s", cfg.StorageBackend)
+	return nil
+}
+
+// Returns the storage backend files are kept in, building the configured one on first use
+func FileStorage() Storage {
+	fileStorageMu.RLock()
+	storage := fileStorage
+	fileStorageMu.RUnlock()
+	if storage != nil {
+		return storage
+	}
+
+	fileStorageMu.Lock()
+	defer fileStorageMu.Unlock()
+	if fileStorage == nil {
+		fileStorage = NewStorage(config.GetConfig())
+	}
+	return fileStorage
+}
+
+// Replaces the storage backend (e.g with a local one in tests). It can be called before or
+// after the first FileStorage call and is safe to use while requests are being served
+func SetFileStorage(storage Storage) {
+	fileStorageMu.Lock()
+	defer fileStorageMu.Unlock()
+	fileStorage = storage
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// Keeps files on cloudinary. Clients post them to the upload url with the signed params
+type CloudinaryStorage struct {
+	cld					*cloudinary.Cloudinary
+	cloudName			string
+	apiSecret			string
+}
+
+func NewCloudinaryStorage(cloudName string, apiKey string, apiSecret string) *CloudinaryStorage {
+	cld, _ := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	return &CloudinaryStorage{cld: cld, cloudName: cloudName, apiSecret: apiSecret}
+}
+
+// Cloudinary adds the extension from the uploaded file itself, so public ids leave it out
+func cloudinaryPublicId(key string) string {
+	return strings.TrimSuffix(key, path.Ext(key))
+}
+
+func (storage *CloudinaryStorage) UploadData(key string, contentType string) SignatureFormat {
+	publicId := cloudinaryPublicId(key)
+	timestamp := time.Now().Unix()
+	params := map[string]interface{}{
+		"public_id": publicId,
+		"timestamp": timestamp,
+	}
+
+	// Convert the params to url.Values
+	values := url.Values{}
+	for k, v := range params {
+		values.Add(k, fmt.Sprintf("%v", v))
+	}
+	resp, err := api.SignParameters(values, storage.apiSecret)
+	if err != nil {
+		log.Fatal("Error signing params: ", err)
+	}
+	return SignatureFormat{
+		PublicId:  publicId,
+		Signature: resp,
+		Timestamp: timestamp,
+		UploadUrl: fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/image/upload", storage.cloudName),
+		Method:    "POST",
+	}
+}
+
+func (storage *CloudinaryStorage) Url(key string) string {
+	// Generate the Cloudinary URL for the existing resource
+	urls, err := storage.cld.Media(key)
+	if err != nil {
+		log.Println("Error generating Cloudinary URL:", err)
+	}
+	url, err := urls.String()
+	if err != nil {
+		log.Println("Error converting to string:", err)
+	}
+	return url
+}
+
+func (storage *CloudinaryStorage) Upload(file io.Reader, key string, contentType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := storage.cld.Upload.Upload(ctx, file, uploader.UploadParams{PublicID: cloudinaryPublicId(key), Overwrite: BoolAddr(true), Faces: BoolAddr(true)})
+	return err
+}
+
+func (storage *CloudinaryStorage) Stat(key string) (StoredFile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	asset, err := storage.cld.Admin.Asset(ctx, admin.AssetParams{PublicID: cloudinaryPublicId(key)})
+	if err != nil {
+		return StoredFile{}, err
+	}
+	if asset.Error.Message != "" || asset.PublicID == "" {
+		return StoredFile{}, ErrFileNotFound
+	}
+	// Cloudinary reports the format it detected from the file's content
+	return StoredFile{ContentType: ContentTypeForExtension(asset.Format), Size: int64(asset.Bytes)}, nil
+}
+
+func (storage *CloudinaryStorage) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := storage.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: cloudinaryPublicId(key), Invalidate: BoolAddr(true)})
+	return err
+}
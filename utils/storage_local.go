@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The path files in local storage are served from, and uploaded to with a PUT
+const LocalMediaPath = "/media"
+
+// Keeps files in a directory on the server, for development and tests. Upload data
+// is signed with the secret key so only clients given one can upload
+type LocalStorage struct {
+	dir					string
+	baseUrl				string
+	secret				string
+}
+
+func NewLocalStorage(dir string, baseUrl string, secret string) *LocalStorage {
+	return &LocalStorage{dir: dir, baseUrl: strings.TrimSuffix(baseUrl, "/"), secret: secret}
+}
+
+// Returns the directory files are kept in
+func (storage *LocalStorage) Dir() string {
+	return storage.dir
+}
+
+// Returns where the file under the key is kept on disk
+func (storage *LocalStorage) Path(key string) string {
+	return filepath.Join(storage.dir, filepath.FromSlash(key))
+}
+
+func (storage *LocalStorage) sign(key string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(storage.secret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", key, timestamp)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks the signature sent with an upload and that it hasn't expired
+func (storage *LocalStorage) VerifyUpload(key string, timestamp int64, signature string) bool {
+	if strings.Contains(key, "..") || time.Since(time.Unix(timestamp, 0)) > UploadExpiry {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(storage.sign(key, timestamp)))
+}
+
+func (storage *LocalStorage) UploadData(key string, contentType string) SignatureFormat {
+	timestamp := time.Now().Unix()
+	signature := storage.sign(key, timestamp)
+	return SignatureFormat{
+		PublicId:  key,
+		Signature: signature,
+		Timestamp: timestamp,
+		UploadUrl: fmt.Sprintf("%s?timestamp=%d&signature=%s", storage.Url(key), timestamp, signature),
+		Method:    "PUT",
+	}
+}
+
+func (storage *LocalStorage) Url(key string) string {
+	return fmt.Sprintf("%s%s/%s", storage.baseUrl, LocalMediaPath, key)
+}
+
+func (storage *LocalStorage) Upload(file io.Reader, key string, contentType string) error {
+	path := storage.Path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	dest, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+	_, err = io.Copy(dest, file)
+	return err
+}
+
+func (storage *LocalStorage) Stat(key string) (StoredFile, error) {
+	file, err := os.Open(storage.Path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return StoredFile{}, ErrFileNotFound
+	} else if err != nil {
+		return StoredFile{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return StoredFile{}, err
+	}
+
+	// Sniff the content rather than trust the extension. Go can't tell some types (e.g tiff) apart
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	contentType := http.DetectContentType(head[:n])
+	if contentType == "application/octet-stream" {
+		contentType = ContentTypeForExtension(strings.TrimPrefix(filepath.Ext(key), "."))
+	}
+	return StoredFile{ContentType: contentType, Size: info.Size()}, nil
+}
+
+func (storage *LocalStorage) Delete(key string) error {
+	err := os.Remove(storage.Path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Keeps files in a bucket of S3 or any S3-compatible service (e.g MinIO, R2). Clients PUT them
+// to presigned URLs, so the bucket needs CORS set up for the frontend
+type S3Storage struct {
+	endpoint			string
+	region				string
+	bucket				string
+	accessKeyId			string
+	secretAccessKey		string
+	publicUrl			string
+	client				*http.Client
+}
+
+// Files are served from publicUrl (e.g a CDN) when it's set, or straight from the bucket otherwise
+func NewS3Storage(endpoint string, region string, bucket string, accessKeyId string, secretAccessKey string, publicUrl string) *S3Storage {
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	return &S3Storage{
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		region:          region,
+		bucket:          bucket,
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+		publicUrl:       strings.TrimSuffix(publicUrl, "/"),
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Objects are addressed path-style since every S3-compatible service supports it
+func (storage *S3Storage) objectUrl(key string) string {
+	return fmt.Sprintf("%s/%s/%s", storage.endpoint, storage.bucket, key)
+}
+
+func hmacSHA256(key []byte, content string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(content))
+	return mac.Sum(nil)
+}
+
+// Returns a URL that allows the request to the object for a while without credentials,
+// along with its signature
+func (storage *S3Storage) presign(method string, key string, expires time.Duration) (string, string) {
+	return PresignS3Url(method, storage.objectUrl(key), storage.region, storage.accessKeyId, storage.secretAccessKey, time.Now(), expires)
+}
+
+// Signs a request URL with AWS Signature Version 4 in the query string, as of the given time
+func PresignS3Url(method string, rawUrl string, region string, accessKeyId string, secretAccessKey string, now time.Time, expires time.Duration) (string, string) {
+	now = now.UTC()
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	objectUrl, _ := url.Parse(rawUrl)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", fmt.Sprintf("%s/%s", accessKeyId, scope))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		objectUrl.EscapedPath(),
+		canonicalQuery,
+		fmt.Sprintf("host:%s\n", objectUrl.Host),
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		query.Get("X-Amz-Date"),
+		scope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	return fmt.Sprintf("%s?%s&X-Amz-Signature=%s", objectUrl.String(), canonicalQuery, signature), signature
+}
+
+func (storage *S3Storage) UploadData(key string, contentType string) SignatureFormat {
+	uploadUrl, signature := storage.presign(http.MethodPut, key, UploadExpiry)
+	return SignatureFormat{
+		PublicId:  key,
+		Signature: signature,
+		Timestamp: time.Now().Unix(),
+		UploadUrl: uploadUrl,
+		Method:    http.MethodPut,
+	}
+}
+
+func (storage *S3Storage) Url(key string) string {
+	if storage.publicUrl != "" {
+		return fmt.Sprintf("%s/%s", storage.publicUrl, key)
+	}
+	return storage.objectUrl(key)
+}
+
+// Sends a presigned request for the object and fails unless S3 answers with a success status
+func (storage *S3Storage) do(method string, key string, body []byte, contentType string) (*http.Response, error) {
+	requestUrl, _ := storage.presign(method, key, time.Minute)
+	req, err := http.NewRequest(method, requestUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := storage.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp, fmt.Errorf("s3 %s %s: %s", method, key, resp.Status)
+	}
+	return resp, nil
+}
+
+func (storage *S3Storage) Upload(file io.Reader, key string, contentType string) error {
+	// S3 needs the content length up front, so the file is read whole
+	body, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	_, err = storage.do(http.MethodPut, key, body, contentType)
+	return err
+}
+
+func (storage *S3Storage) Stat(key string) (StoredFile, error) {
+	resp, err := storage.do(http.MethodHead, key, nil, "")
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return StoredFile{}, ErrFileNotFound
+	} else if err != nil {
+		return StoredFile{}, err
+	}
+	// S3 keeps the content type the client uploaded the file with
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return StoredFile{ContentType: contentType, Size: resp.ContentLength}, nil
+}
+
+func (storage *S3Storage) Delete(key string) error {
+	// S3 answers deletes of missing objects with a success status too
+	_, err := storage.do(http.MethodDelete, key, nil, "")
+	return err
+}
@@ -76,10 +76,16 @@ func AssignFields(src interface{}, dest interface{}) {
 				} else if destField.Type() == reflect.TypeOf(decimal.Decimal{}) && srcField.Elem().Kind() == reflect.Float64 {
 					decimalValue := decimal.NewFromFloat(srcField.Elem().Float())
 					destField.Set(reflect.ValueOf(decimalValue))
+				} else if destField.Type() == reflect.TypeOf(&decimal.Decimal{}) && srcField.Elem().Kind() == reflect.Float64 {
+					decimalValue := DecimalParser(srcField.Elem().Float())
+					destField.Set(reflect.ValueOf(&decimalValue))
 				} else if destField.Type() == reflect.TypeOf(time.Time{}) && srcField.Elem().Kind() == reflect.String {
 					dateString := srcField.Elem().String()
 					parsedTime := TimeParser(dateString)
 					destField.Set(reflect.ValueOf(parsedTime))
+				} else if destField.Type() == reflect.TypeOf(&time.Time{}) && srcField.Elem().Kind() == reflect.String {
+					parsedTime := TimeParser(srcField.Elem().String())
+					destField.Set(reflect.ValueOf(&parsedTime))
 				} else {
 					destField.Set(srcField.Elem())
 				}
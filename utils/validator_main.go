@@ -31,6 +31,8 @@ func init() {
     customValidator.RegisterValidation("date", DateValidator)
     customValidator.RegisterValidation("closing_date_validator", ClosingDateValidator)
     customValidator.RegisterValidation("file_type_validator", FileTypeValidator)
+    customValidator.RegisterValidation("currency_code", CurrencyCodeValidator)
+    customValidator.RegisterValidation("attribute_name", AttributeNameValidator)
 
 
 	customValidator.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -60,6 +62,16 @@ func registerTranslations(param string) {
     registerTranslation("closing_date_validator", "Closing date must be beyond the current datetime!", translator)
     registerTranslation("file_type_validator", "Invalid file type", translator)
     registerTranslation("required", "This field is required.", translator)
+    registerTranslation("required_if", "This field is required.", translator)
+    registerTranslation("required_without", "This field is required.", translator)
+    registerTranslation("oneof", fmt.Sprintf("Must be one of: %s", param), translator)
+    registerTranslation("ltfield", "Value is too large!", translator)
+    registerTranslation("gtfield", "Value is too small!", translator)
+    registerTranslation("currency_code", "Invalid currency code!", translator)
+    registerTranslation("attribute_name", "Use lowercase letters, digits and underscores only!", translator)
+    registerTranslation("lte", "Value is too large!", translator)
+    registerTranslation("gte", "Value is too small!", translator)
+    registerTranslation("uuid", "Invalid uuid!", translator)
 
     minErrMsg := fmt.Sprintf("%s characters min", param)
     registerTranslation("min", minErrMsg, translator)
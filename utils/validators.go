@@ -2,6 +2,7 @@ package utils
 
 import (
 	"log"
+	"regexp"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -40,3 +41,17 @@ func FileTypeValidator(fl validator.FieldLevel) bool {
 	}
 	return fileTypeFound
 }
+
+var currencyCodeRegex = regexp.MustCompile("^[A-Z]{3}$")
+
+// Validates the shape of an ISO 4217 currency code (e.g USD)
+func CurrencyCodeValidator(fl validator.FieldLevel) bool {
+	return currencyCodeRegex.MatchString(fl.Field().String())
+}
+
+var AttributeNameRegex = regexp.MustCompile("^[a-z][a-z0-9_]*$")
+
+// Validates the key of a listing attribute (e.g mileage, storage_gb)
+func AttributeNameValidator(fl validator.FieldLevel) bool {
+	return AttributeNameRegex.MatchString(fl.Field().String())
+}